@@ -1,6 +1,6 @@
 module "consul" {
   source = "hashicorp/consul/aws"
-  version = "~0.7.3"
+  version = "~> 0.7.3"
 }
 
 module "consul_github_https_missing_ref" {
@@ -19,7 +19,7 @@ module "consul_github_https" {
 
 module "consul_github_ssh" {
   source = "git@github.com:hashicorp/terraform-aws-consul?ref=0.1.0"
-  version = "~0.1.0"
+  version = "~> 0.1.0"
 }
 
 module "example_git_ssh_branch" {
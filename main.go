@@ -10,19 +10,30 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/archive"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/cache"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/configfile"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/credentials"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/gitauth"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/httputil"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/modulecall"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/oci"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/output"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/registry"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/rewrite"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/scan"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/update"
 
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/Masterminds/semver/v3"
 	"github.com/peterbourgon/ff/v3/ffcli"
 	"github.com/sgreben/flagvar"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -30,8 +41,11 @@ var (
 	version       = "3-SNAPSHOT"
 	updatesClient = update.Client{
 		Registry: registry.Client{
-			HTTP: http.DefaultClient,
+			HTTP:  &http.Client{},
+			Retry: registry.DefaultRetry,
 		},
+		OCI:  oci.Client{HTTP: &http.Client{}},
+		HTTP: &http.Client{},
 	}
 	config struct {
 		Paths                           []string
@@ -45,6 +59,31 @@ var (
 		All                             bool
 		GenerateSed                     bool
 		IncludePrereleaseVersions       bool
+		Recursive                       bool
+		Include                         flagvar.Globs
+		Exclude                         flagvar.Globs
+		FollowLocal                     bool
+		Installed                       bool
+		Providers                       bool
+		Core                            bool
+		Lock                            bool
+		Parallelism                     int
+		Timeout                         time.Duration
+		Cache                           bool
+		CacheDir                        string
+		CacheTTL                        time.Duration
+		CacheExpiredOnly                bool
+		MastermindsGitConstraints       bool
+		ConfigFile                      string
+		TagPatterns                     flagvar.Assignments
+		TagPrefixes                     flagvar.Assignments
+		TagRules                        tags.Rules
+		GitRewrites                     flagvar.Assignments
+		RegistryRewrites                flagvar.Assignments
+		ArchiveIndexes                  flagvar.Assignments
+		ArchiveIndexRules               archive.Rules
+		Metadata                        bool
+		GitTagDates                     bool
 	}
 )
 
@@ -57,10 +96,14 @@ func main() {
 	config.Output.Value = string(output.FormatMarkdown)
 	config.OutputFormat = output.FormatMarkdown
 	config.RegistryHeaders.Separator = ":"
+	config.Include.Separators = &[]rune{'/'}
+	config.Exclude.Separators = &[]rune{'/'}
 
 	rootFlagSet := flag.NewFlagSet(appName, flag.ExitOnError)
 	listFlagSet := flag.NewFlagSet(appName+" list", flag.ExitOnError)
 	checkFlagSet := flag.NewFlagSet(appName+" check", flag.ExitOnError)
+	cacheListFlagSet := flag.NewFlagSet(appName+" cache list", flag.ExitOnError)
+	cacheClearFlagSet := flag.NewFlagSet(appName+" cache clear", flag.ExitOnError)
 
 	rootFlagSet.BoolVar(&config.Quiet, "quiet", false, "suppress log output (stderr)")
 	rootFlagSet.BoolVar(&config.Quiet, "q", false, "(alias for -quiet)")
@@ -79,9 +122,53 @@ func main() {
 	checkFlagSet.BoolVar(&config.All, "all", config.All, "include modules without updates")
 	listFlagSet.Var(&config.ModuleNames, "module", "include this module (may be specified repeatedly. by default, all modules are included)")
 	checkFlagSet.Var(&config.ModuleNames, "module", "include this module (may be specified repeatedly. by default, all modules are included)")
+	for _, fs := range []*flag.FlagSet{listFlagSet, checkFlagSet} {
+		fs.BoolVar(&config.Recursive, "recursive", config.Recursive, "scan all directories containing terraform files below the given paths")
+		fs.BoolVar(&config.Recursive, "r", config.Recursive, "(alias for -recursive)")
+		fs.Var(&config.Include, "include", fmt.Sprintf("with -recursive, only scan directories whose path relative to the scanned path matches (%s, may be specified repeatedly)", config.Include.Help()))
+		fs.Var(&config.Exclude, "exclude", fmt.Sprintf("with -recursive, skip directories whose path relative to the scanned path matches (%s, may be specified repeatedly)", config.Exclude.Help()))
+		fs.BoolVar(&config.FollowLocal, "follow-local", config.FollowLocal, "also scan the modules referenced by local module calls (transitively)")
+		fs.BoolVar(&config.Installed, "installed", config.Installed, "read the module versions installed by terraform init from .terraform/modules/modules.json")
+		fs.BoolVar(&config.Providers, "providers", config.Providers, "also include the providers from required_providers blocks")
+		fs.BoolVar(&config.Core, "core", config.Core, "also include the terraform core version constraints (required_version)")
+		fs.BoolVar(&config.Lock, "lock", config.Lock, "also include the provider versions locked in .terraform.lock.hcl")
+		fs.StringVar(&config.ConfigFile, "config", config.ConfigFile, "read settings (tag_pattern, archive_index, rewrite_git and rewrite_registry blocks) from this HCL file")
+		fs.Var(&config.TagPatterns, "tag-pattern", "read versions of git sources whose remote (host/path) matches the glob REMOTE from tags matching the regex PATTERN, with a named group (?P<version>...) (REMOTE=PATTERN, may be specified repeatedly; for bucket and HTTP archive sources, REMOTE matches the archive's bucket or host and directory, PATTERN the archive names)")
+		fs.Var(&config.TagPrefixes, "tag-prefix", "read versions of git sources whose remote (host/path) matches the glob REMOTE from tags starting with PREFIX (REMOTE=PREFIX, may be specified repeatedly)")
+		fs.BoolVar(&config.MastermindsGitConstraints, "masterminds-git-constraints", config.MastermindsGitConstraints, "evaluate version constraints of git sources with Masterminds semver rules (e.g. ~1.2, ^1.2) instead of Terraform's")
+	}
 	checkFlagSet.Var(&config.RegistryHeaders, "H", "(alias for -registry-header)")
-	checkFlagSet.Var(&config.RegistryHeaders, "registry-header", fmt.Sprintf("extra HTTP headers for requests to Terraform module registries (%s, may be specified repeatedly)", config.RegistryHeaders.Help()))
+	checkFlagSet.Var(&config.RegistryHeaders, "registry-header", fmt.Sprintf("extra HTTP headers for requests to Terraform module registries (all hosts) and HTTP archive sources (%s, may be specified repeatedly)", config.RegistryHeaders.Help()))
+	checkFlagSet.IntVar(&updatesClient.Registry.Retry.MaxRetries, "registry-retries", updatesClient.Registry.Retry.MaxRetries, "number of retries of registry requests failing with 429, 5xx or network errors")
+	checkFlagSet.DurationVar(&updatesClient.Registry.Retry.MinDelay, "registry-retry-delay", updatesClient.Registry.Retry.MinDelay, "delay before the first retry of a registry request, doubling with each further retry")
+	checkFlagSet.DurationVar(&updatesClient.Registry.Retry.MaxDelay, "registry-retry-max-delay", updatesClient.Registry.Retry.MaxDelay, "maximum delay between retries of registry requests; requests asked (via Retry-After) to wait longer are not retried")
+	checkFlagSet.IntVar(&config.Parallelism, "parallelism", 8, "number of module sources to query concurrently")
+	checkFlagSet.DurationVar(&config.Timeout, "timeout", 0, "overall time limit for checking module sources (e.g. 5m, default: no limit)")
+	checkFlagSet.DurationVar(&updatesClient.RequestTimeout, "request-timeout", 30*time.Second, "time limit for fetching the versions of a single module source (0: no limit)")
+	checkFlagSet.StringVar(&updatesClient.CoreReleasesURL, "core-releases-url", update.DefaultCoreReleasesURL, "URL or local path of the HashiCorp releases index (index.json) used with -core")
+	checkFlagSet.StringVar(&updatesClient.S3.Endpoint, "s3-endpoint", "", "list the objects of s3:: sources at this S3-compatible endpoint URL instead (e.g. http://localhost:9000)")
+	checkFlagSet.StringVar(&updatesClient.GCS.Endpoint, "gcs-endpoint", "", "list the objects of gcs:: sources at this storage API endpoint URL instead (e.g. an emulator)")
+	checkFlagSet.Var(&config.GitRewrites, "rewrite-git", "look up versions of git remotes starting with PREFIX at URL instead, keeping PREFIX in the output (PREFIX=URL, may be specified repeatedly; like git's url.<URL>.insteadOf)")
+	checkFlagSet.Var(&config.ArchiveIndexes, "archive-index", "list the archives of HTTP sources whose host/directory matches the glob REMOTE from the JSON index at URL instead of the directory's HTML listing (REMOTE=URL, may be specified repeatedly)")
+	checkFlagSet.Var(&config.RegistryRewrites, "rewrite-registry", "look up versions from the registry HOSTNAME at PROXY_HOSTNAME instead, keeping HOSTNAME in the output (HOSTNAME=PROXY_HOSTNAME, may be specified repeatedly)")
+	checkFlagSet.BoolVar(&config.Metadata, "metadata", config.Metadata, "fetch registry module metadata (publish dates, verified, downloads, source repository, deprecation) for the current and latest versions")
+	checkFlagSet.BoolVar(&config.GitTagDates, "git-tag-dates", config.GitTagDates, "fetch the current and latest version tags of git sources to show their release dates (tagger or commit dates)")
+	checkFlagSet.Func("min-age", "hold back versions released less than this long ago (e.g. 7d, 36h), using registry publish dates and git tag dates; held back versions are reported as pending cooldown", func(value string) (err error) {
+		updatesClient.MinAge, err = parseAge(value)
+		return err
+	})
+	checkFlagSet.IntVar(&updatesClient.AheadBehindDepth, "branch-history-depth", update.DefaultAheadBehindDepth, "number of commits of history to fetch when comparing branch refs with the latest version tag (0: all)")
 	checkFlagSet.BoolVar(&config.GenerateSed, "sed", config.GenerateSed, "generate sed statements for upgrade")
+	checkFlagSet.BoolVar(&config.Cache, "cache", config.Cache, "cache the versions of module sources on disk (see -cache-dir, -cache-ttl)")
+	checkFlagSet.BoolVar(&updatesClient.Refresh, "refresh", updatesClient.Refresh, "with -cache, ignore cached versions and fetch them again")
+	defaultCacheDir, _ := cache.DefaultPath(appName)
+	for _, fs := range []*flag.FlagSet{checkFlagSet, cacheListFlagSet, cacheClearFlagSet} {
+		fs.StringVar(&config.CacheDir, "cache-dir", defaultCacheDir, "directory of the version cache")
+		fs.DurationVar(&config.CacheTTL, "cache-ttl", time.Hour, "time after which cached versions expire (0: never)")
+	}
+	cacheListFlagSet.Var(&config.Output, "output", "output format, "+config.Output.Help())
+	cacheListFlagSet.Var(&config.Output, "o", "(alias for -output)")
+	cacheClearFlagSet.BoolVar(&config.CacheExpiredOnly, "expired", config.CacheExpiredOnly, "only remove expired entries")
 
 	cmdList := &ffcli.Command{
 		Name:       "list",
@@ -101,14 +188,55 @@ func main() {
 		ShortUsage: appName + " check [options] [<path> ...]",
 		ShortHelp:  "Check referenced terraform modules' sources for newer versions",
 		FlagSet:    checkFlagSet,
-		Exec: func(_ context.Context, args []string) error {
+		Exec: func(ctx context.Context, args []string) error {
 			config.Paths = args
-			updates(scanForModuleCalls())
+			if config.Cache {
+				updatesClient.Cache = cacheDir()
+			}
+			if config.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+				defer cancel()
+			}
+			updates(ctx, scanForModuleCalls())
 			return nil
 		},
 	}
 	cmdCheck.LongHelp = cmdCheck.ShortHelp
 
+	cmdCacheList := &ffcli.Command{
+		Name:       "list",
+		ShortUsage: appName + " cache list [options]",
+		ShortHelp:  "List the entries of the version cache",
+		FlagSet:    cacheListFlagSet,
+		Exec: func(_ context.Context, args []string) error {
+			return cacheList(cacheDir())
+		},
+	}
+	cmdCacheList.LongHelp = cmdCacheList.ShortHelp
+
+	cmdCacheClear := &ffcli.Command{
+		Name:       "clear",
+		ShortUsage: appName + " cache clear [options] [<key> ...]",
+		ShortHelp:  "Remove the given (by default: all) entries from the version cache",
+		FlagSet:    cacheClearFlagSet,
+		Exec: func(_ context.Context, args []string) error {
+			return cacheClear(cacheDir(), args)
+		},
+	}
+	cmdCacheClear.LongHelp = cmdCacheClear.ShortHelp
+
+	cmdCache := &ffcli.Command{
+		Name:        "cache",
+		ShortUsage:  appName + " cache <subcommand>",
+		ShortHelp:   "Inspect and clear the version cache used by check -cache",
+		Subcommands: []*ffcli.Command{cmdCacheList, cmdCacheClear},
+		Exec: func(_ context.Context, args []string) error {
+			return flag.ErrHelp
+		},
+	}
+	cmdCache.LongHelp = cmdCache.ShortHelp
+
 	cmdVersion := &ffcli.Command{
 		Name:       "version",
 		ShortUsage: appName + " version",
@@ -124,7 +252,7 @@ func main() {
 	cmdRoot := &ffcli.Command{
 		ShortUsage:  appName + " [options] <subcommand>",
 		FlagSet:     rootFlagSet,
-		Subcommands: []*ffcli.Command{cmdList, cmdCheck, cmdVersion},
+		Subcommands: []*ffcli.Command{cmdList, cmdCheck, cmdCache, cmdVersion},
 		Exec: func(_ context.Context, args []string) error {
 			return flag.ErrHelp
 		},
@@ -144,24 +272,157 @@ func main() {
 		for _, kv := range config.RegistryHeaders.Values {
 			headers.Add(kv.Key, strings.TrimLeftFunc(kv.Value, unicode.IsSpace))
 		}
-		updatesClient.Registry.HTTP.Transport = httputil.AddHeadersRoundtripper{
+		transport := httputil.AddHeadersRoundtripper{
 			Headers: headers,
 			Nested:  http.DefaultTransport,
 		}
+		updatesClient.Registry.HTTP = &http.Client{Transport: transport}
+		updatesClient.ArchiveHTTP = &http.Client{Transport: transport}
 	}
-	if githubToken := os.Getenv("GITHUB_TOKEN"); githubToken != "" {
-		updatesClient.GitAuth = &githttp.BasicAuth{
-			Username: githubToken,
-		}
+	if tokens, err := credentials.Load(); err != nil {
+		log.Printf("error: load terraform credentials: %v", err)
+	} else {
+		updatesClient.Registry.Credentials = tokens
+	}
+	if err := loadRules(); err != nil {
+		log.Fatal(err)
 	}
+	updatesClient.GitCredentials = gitauth.Default()
 	if err := cmdRoot.Run(context.Background()); err != nil && !errors.Is(err, flag.ErrHelp) {
 		log.Fatal(err)
 	}
 }
 
-func scanForModuleCalls() []scan.Result {
-	scanResults, err := scan.Scan(config.Paths)
+// loadRules collects the tag patterns and rewrite rules given as flags and in the config file, in that order of precedence.
+func loadRules() error {
+	for _, kv := range config.TagPatterns.Values {
+		rule, err := tags.NewRule(kv.Key, "", kv.Value, "")
+		if err != nil {
+			return err
+		}
+		config.TagRules = append(config.TagRules, *rule)
+	}
+	for _, kv := range config.TagPrefixes.Values {
+		rule, err := tags.NewRule(kv.Key, "", "", kv.Value)
+		if err != nil {
+			return err
+		}
+		config.TagRules = append(config.TagRules, *rule)
+	}
+	for _, kv := range config.ArchiveIndexes.Values {
+		rule, err := archive.NewRule(kv.Key, kv.Value)
+		if err != nil {
+			return err
+		}
+		config.ArchiveIndexRules = append(config.ArchiveIndexRules, *rule)
+	}
+	rewrites := &updatesClient.Rewrites
+	for _, kv := range config.GitRewrites.Values {
+		rule, err := rewrite.NewRule(kv.Value, kv.Key)
+		if err != nil {
+			return fmt.Errorf("-rewrite-git: %w", err)
+		}
+		rewrites.Git = append(rewrites.Git, *rule)
+	}
+	for _, kv := range config.RegistryRewrites.Values {
+		rule, err := rewrite.NewRule(kv.Value, kv.Key)
+		if err != nil {
+			return fmt.Errorf("-rewrite-registry: %w", err)
+		}
+		rewrites.Registry = append(rewrites.Registry, *rule)
+	}
+	if config.ConfigFile == "" {
+		return nil
+	}
+	file, err := configfile.Load(config.ConfigFile)
+	if err != nil {
+		return err
+	}
+	tagRules, err := file.TagRules()
+	if err != nil {
+		return fmt.Errorf("read config file %q: %w", config.ConfigFile, err)
+	}
+	config.TagRules = append(config.TagRules, tagRules...)
+	indexRules, err := file.ArchiveIndexRules()
+	if err != nil {
+		return fmt.Errorf("read config file %q: %w", config.ConfigFile, err)
+	}
+	config.ArchiveIndexRules = append(config.ArchiveIndexRules, indexRules...)
+	fileRewrites, err := file.RewriteRules()
+	if err != nil {
+		return fmt.Errorf("read config file %q: %w", config.ConfigFile, err)
+	}
+	rewrites.Git = append(rewrites.Git, fileRewrites.Git...)
+	rewrites.Registry = append(rewrites.Registry, fileRewrites.Registry...)
+	return nil
+}
+
+func cacheDir() *cache.Dir {
+	if config.CacheDir == "" {
+		log.Fatal("no cache directory: specify -cache-dir")
+	}
+	return &cache.Dir{Path: config.CacheDir, TTL: config.CacheTTL}
+}
+
+func cacheList(dir *cache.Dir) error {
+	entries, err := dir.List()
 	if err != nil {
+		return err
+	}
+	now := time.Now()
+	out := make(output.CacheEntries, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, output.CacheEntry{
+			Key:       e.Key,
+			FetchedAt: e.FetchedAt,
+			Expired:   e.Expired(dir.TTL, now),
+			Versions:  e.Versions,
+		})
+	}
+	sort.Sort(out)
+	return out.Write(os.Stdout, config.OutputFormat)
+}
+
+func cacheClear(dir *cache.Dir, keys []string) error {
+	if len(keys) == 0 && !config.CacheExpiredOnly {
+		return dir.Clear()
+	}
+	if len(keys) == 0 {
+		entries, err := dir.List()
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+	}
+	for _, key := range keys {
+		if config.CacheExpiredOnly {
+			if _, ok := dir.Get(key); ok {
+				continue
+			}
+		}
+		if err := dir.Remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanForModuleCalls() []scan.Result {
+	scanResults, err := scan.Scan(config.Paths, scan.Options{
+		Recursive:   config.Recursive,
+		Include:     config.Include.Values,
+		Exclude:     config.Exclude.Values,
+		FollowLocal: config.FollowLocal,
+		Installed:   config.Installed,
+		Providers:   config.Providers,
+		Core:        config.Core,
+		Lock:        config.Lock,
+	})
+	if errors.Is(err, scan.ErrModuleCycle) {
+		log.Printf("error: %v", err)
+	} else if err != nil {
 		log.Fatal(err)
 	}
 	moduleNamesFilter := config.ModuleNames.Value
@@ -177,26 +438,49 @@ func scanForModuleCalls() []scan.Result {
 	return scanResultsFiltered
 }
 
+func parse(m scan.Result) (*modulecall.Parsed, error) {
+	switch m.Kind {
+	case scan.KindProvider, scan.KindLock:
+		return modulecall.ParseProvider(m.ModuleCall)
+	case scan.KindCore:
+		return modulecall.ParseCore(m.ModuleCall)
+	}
+	return modulecall.Parse(m.ModuleCall, modulecall.Options{
+		MastermindsGitConstraints: config.MastermindsGitConstraints,
+		TagPatterns:               config.TagRules,
+		ArchiveIndexes:            config.ArchiveIndexRules,
+	})
+}
+
 func list(scanResults []scan.Result) {
 	var out output.Modules
 	for _, m := range scanResults {
-		parsed, err := modulecall.Parse(m.ModuleCall)
+		parsed, err := parse(m)
 		if err != nil {
 			log.Printf("error: %v", err)
 			out = append(out, output.Module{
+				Kind:              string(m.Kind),
+				Root:              m.Root,
 				Path:              m.Path,
 				Name:              m.ModuleCall.Name,
+				Parents:           m.ParentNames(),
 				Source:            m.ModuleCall.Source,
 				VersionConstraint: m.ModuleCall.Version,
+				Installed:         m.InstalledVersion,
 			})
 			continue
 		}
 		out = append(out, output.Module{
+			Kind:              string(m.Kind),
+			Root:              m.Root,
 			Path:              m.Path,
 			Name:              m.ModuleCall.Name,
+			Parents:           m.ParentNames(),
 			Source:            m.ModuleCall.Source,
+			Subdir:            parsed.Source.Subdir(),
 			VersionConstraint: parsed.ConstraintsString,
 			Version:           parsed.VersionString,
+			Installed:         m.InstalledVersion,
 			Type:              parsed.Source.Type(),
 		})
 	}
@@ -206,48 +490,195 @@ func list(scanResults []scan.Result) {
 	}
 }
 
-func updates(scanResults []scan.Result) {
+// parseAge parses a duration, also accepting whole days (e.g. 7d).
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func checkUpdate(ctx context.Context, m scan.Result) (*output.Update, error) {
+	parsed, err := parse(m)
+	if err != nil {
+		return nil, err
+	}
+	current, currentString := parsed.Version, parsed.VersionString
+	pinned := current != nil
+	// a commit ref pins the version(s) tagged at that commit
+	var inferred []string
+	if git := parsed.Source.Git; git != nil && current == nil {
+		if commit, ok := git.Commit(); ok {
+			tagged, err := updatesClient.TagsAt(ctx, *parsed.Source, commit)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range tagged {
+				inferred = append(inferred, t.Name)
+			}
+			if len(tagged) > 0 {
+				current, pinned = tagged[len(tagged)-1].Version, true
+			}
+		}
+	}
+	// without a pinned version, compare against the installed version (if known)
+	var installed *semver.Version
+	if m.InstalledVersion != "" {
+		installed, err = semver.NewVersion(m.InstalledVersion)
+		if err != nil {
+			log.Printf("error: parse installed version %q of %q: %v", m.InstalledVersion, m.ModuleCall.Name, err)
+		}
+	}
+	if current == nil && installed != nil {
+		current, currentString = installed, m.InstalledVersion
+	}
+	update, err := updatesClient.Update(ctx, *parsed.Source, current, parsed.Constraints, config.IncludePrereleaseVersions)
+	if err != nil {
+		return nil, err
+	}
+	out := output.Update{
+		Kind:              string(m.Kind),
+		Root:              m.Root,
+		Path:              m.Path,
+		Name:              m.ModuleCall.Name,
+		Parents:           m.ParentNames(),
+		Source:            m.ModuleCall.Source,
+		Subdir:            parsed.Source.Subdir(),
+		VersionConstraint: parsed.ConstraintsString,
+		Version:           currentString,
+		InferredVersions:  inferred,
+		LatestMatching:    update.LatestMatchingVersion,
+		MatchingUpdate:    pinned && update.LatestMatchingUpdate != "",
+		LatestOverall:     update.LatestOverallVersion,
+		PendingCooldown:   update.Pending,
+		NonMatchingUpdate: update.LatestOverallUpdate != "" && update.LatestOverallUpdate != update.LatestMatchingVersion,
+		Installed:         m.InstalledVersion,
+	}
+	if update.CooldownErr != nil {
+		log.Printf("error: check release dates of %q for -min-age: %v", m.ModuleCall.Name, update.CooldownErr)
+		out.CooldownError = update.CooldownErr.Error()
+	}
+	if git := parsed.Source.Git; git != nil && !pinned && git.RefValue != nil {
+		if err := checkBranch(ctx, parsed, update, &out); err != nil {
+			return nil, err
+		}
+	}
+	if config.GitTagDates && parsed.Source.Git != nil {
+		if err := updatesClient.AddTagDates(ctx, *parsed.Source, update); err != nil {
+			log.Printf("error: %v", err)
+		}
+	}
+	if released, ok := update.Released[update.CurrentVersion]; ok {
+		out.PublishedAt = &released
+	}
+	if released, ok := update.Released[update.LatestOverallVersion]; ok {
+		out.LatestPublishedAt = &released
+	}
+	if config.Metadata && parsed.Source.Registry != nil {
+		addMetadata(ctx, parsed, current, update, &out)
+	}
+	if installed != nil && update.LatestMatchingVersion != "" {
+		latestMatching, err := semver.NewVersion(update.LatestMatchingVersion)
+		out.InstalledUpdate = err == nil && !installed.Equal(latestMatching)
+	}
+	return &out, nil
+}
+
+// checkBranch flags git sources whose ref names a branch, recommending the latest version tag as a pin.
+func checkBranch(ctx context.Context, parsed *modulecall.Parsed, u *update.Update, out *output.Update) error {
+	branch := *parsed.Source.Git.RefValue
+	if _, ok := parsed.Source.Git.Commit(); ok {
+		return nil
+	}
+	isBranch, err := updatesClient.IsBranch(ctx, *parsed.Source, branch)
+	if err != nil || !isBranch {
+		return err
+	}
+	out.Status = output.StatusUnpinnedBranch
+	out.RecommendedPin = u.LatestMatchingVersion
+	if out.RecommendedPin == "" {
+		out.RecommendedPin = u.LatestOverallVersion
+	}
+	if out.RecommendedPin == "" {
+		return nil
+	}
+	ahead, behind, err := updatesClient.AheadBehind(ctx, *parsed.Source, branch, out.RecommendedPin)
+	if err != nil {
+		log.Printf("error: %v", err)
+		return nil
+	}
+	out.CommitsAhead, out.CommitsBehind = &ahead, &behind
+	return nil
+}
+
+// addMetadata adds the registry metadata of the current and latest versions; failures to fetch it are only logged.
+func addMetadata(ctx context.Context, parsed *modulecall.Parsed, current *semver.Version, u *update.Update, out *output.Update) {
+	if current != nil {
+		module, err := updatesClient.Module(ctx, *parsed.Source, current.Original())
+		if err != nil {
+			log.Printf("error: %v", err)
+		} else {
+			out.PublishedAt = &module.PublishedAt
+			out.Verified, out.Downloads, out.Repository = module.Verified, module.Downloads, module.Source
+			if d := module.Deprecation; d != nil {
+				out.Deprecation = &output.Deprecation{Reason: d.Reason, Link: d.Link}
+			}
+		}
+	}
+	if u.LatestOverallVersion == "" {
+		return
+	}
+	latest, err := updatesClient.Module(ctx, *parsed.Source, u.LatestOverallVersion)
+	if err != nil {
+		log.Printf("error: %v", err)
+		return
+	}
+	out.LatestPublishedAt = &latest.PublishedAt
+	if out.Repository == "" {
+		out.Verified, out.Downloads, out.Repository = latest.Verified, latest.Downloads, latest.Source
+	}
+}
+
+func updates(ctx context.Context, scanResults []scan.Result) {
 	var (
 		out                  output.Updates
 		foundMatchingUpdates bool
 		foundAnyUpdates      bool
 	)
-	for _, m := range scanResults {
-		parsed, err := modulecall.Parse(m.ModuleCall)
-		if err != nil {
-			log.Printf("error: %v", err)
-			continue
-		}
-		update, err := updatesClient.Update(*parsed.Source, parsed.Version, parsed.Constraints, config.IncludePrereleaseVersions)
-		if err != nil {
+	results := make([]*output.Update, len(scanResults))
+	errs := make([]error, len(scanResults))
+	var g errgroup.Group
+	g.SetLimit(max(config.Parallelism, 1))
+	for i, m := range scanResults {
+		g.Go(func() error {
+			results[i], errs[i] = checkUpdate(ctx, m)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	for i, updateOutput := range results {
+		if err := errs[i]; err != nil {
 			log.Printf("error: %v", err)
 			continue
 		}
-		updateOutput := output.Update{
-			Path:              m.Path,
-			Name:              m.ModuleCall.Name,
-			Source:            m.ModuleCall.Source,
-			VersionConstraint: parsed.ConstraintsString,
-			Version:           parsed.VersionString,
-			LatestMatching:    update.LatestMatchingVersion,
-			MatchingUpdate:    update.LatestMatchingUpdate != "",
-			LatestOverall:     update.LatestOverallVersion,
-			NonMatchingUpdate: update.LatestOverallUpdate != "" && update.LatestOverallUpdate != update.LatestMatchingVersion,
-		}
 		hasUpdate := false
-		if updateOutput.MatchingUpdate {
+		if updateOutput.MatchingUpdate || updateOutput.InstalledUpdate {
 			foundMatchingUpdates = true
 			foundAnyUpdates = true
 			hasUpdate = true
 		}
-		if updateOutput.NonMatchingUpdate {
+		if updateOutput.NonMatchingUpdate || updateOutput.Status != "" || updateOutput.Deprecation != nil {
 			foundAnyUpdates = true
 			hasUpdate = true
 		}
 		if !config.All && !hasUpdate {
 			continue
 		}
-		out = append(out, updateOutput)
+		out = append(out, *updateOutput)
 	}
 	sort.Sort(out)
 	if err := out.Format(os.Stdout, config.OutputFormat); err != nil {
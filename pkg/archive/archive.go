@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+
+	"github.com/gobwas/glob"
+)
+
+// Rule selects the JSON index listing the archives of HTTP sources whose host and directory match Remote.
+type Rule struct {
+	// Remote matches the archive URL's host and directory (e.g. `artifacts.internal/modules`); nil matches all.
+	Remote glob.Glob
+	Index  string
+}
+
+type Rules []Rule
+
+// NewRule compiles a rule from its (possibly empty) remote glob and index URL.
+func NewRule(remote, index string) (*Rule, error) {
+	out := Rule{Index: index}
+	if remote != "" {
+		var err error
+		out.Remote, err = glob.Compile(remote, '/')
+		if err != nil {
+			return nil, fmt.Errorf("parse remote glob %q: %w", remote, err)
+		}
+	}
+	return &out, nil
+}
+
+// Match returns the index URL for the given host and directory, or "" if no rule matches.
+// The first matching rule wins.
+func (r Rules) Match(remote string) string {
+	for _, rule := range r {
+		if rule.Remote == nil || rule.Remote.Match(remote) {
+			return rule.Index
+		}
+	}
+	return ""
+}
+
+// List returns the names of the archives in the directory dirURL,
+// as listed in the JSON index at indexURL or, if that is empty, in the directory's HTML listing.
+func List(ctx context.Context, client *http.Client, dirURL, indexURL string) ([]string, error) {
+	dir, err := url.Parse(dirURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse directory url: %w", err)
+	}
+	var (
+		base  = dir
+		links []string
+	)
+	if indexURL != "" {
+		base, err = dir.Parse(indexURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse index url: %w", err)
+		}
+		links, err = indexLinks(ctx, client, base)
+	} else {
+		links, err = directoryLinks(ctx, client, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		target, err := base.Parse(link)
+		if err != nil || target.Host != dir.Host || path.Dir(target.Path) != path.Clean(dir.Path) {
+			continue
+		}
+		name := path.Base(target.Path)
+		if name == "/" || name == "." || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// indexLinks reads a JSON index: an array of archive URLs, relative to the index's location or absolute.
+func indexLinks(ctx context.Context, client *http.Client, index *url.URL) ([]string, error) {
+	body, err := get(ctx, client, index)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var out []string
+	if err := json.NewDecoder(body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode index %q: %w", index, err)
+	}
+	return out, nil
+}
+
+var href = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// directoryLinks scrapes the links from an HTML directory listing (as served by e.g. nginx, Apache, or Artifactory).
+func directoryLinks(ctx context.Context, client *http.Client, dir *url.URL) ([]string, error) {
+	body, err := get(ctx, client, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", dir, err)
+	}
+	var out []string
+	for _, match := range href.FindAllStringSubmatch(string(page), -1) {
+		out = append(out, html.UnescapeString(match[1]+match[2]+match[3]))
+	}
+	return out, nil
+}
+
+func get(ctx context.Context, client *http.Client, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", u, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", u, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %q: %s", u, resp.Status)
+	}
+	return resp.Body, nil
+}
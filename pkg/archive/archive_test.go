@@ -0,0 +1,63 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestList(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/modules/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><h1>Index of /modules/</h1><a href="../">../</a>
+<a href="dns-1.2.0.tar.gz">dns-1.2.0.tar.gz</a>
+<a HREF='/modules/dns-1.3.0.tar.gz'>dns-1.3.0.tar.gz</a>
+<a href="nested/">nested/</a>
+<a href="https://elsewhere.example.com/modules/dns-9.9.9.tar.gz">mirror</a>
+</body></html>`)
+	})
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `["modules/dns-1.2.0.tar.gz", "modules/dns-2.0.0.tar.gz", "other/dns-3.0.0.tar.gz"]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		index string
+		want  []string
+	}{
+		{"html listing", "", []string{"dns-1.2.0.tar.gz", "dns-1.3.0.tar.gz"}},
+		{"json index", server.URL + "/index.json", []string{"dns-1.2.0.tar.gz", "dns-2.0.0.tar.gz"}},
+		{"relative json index", "../index.json", []string{"dns-1.2.0.tar.gz", "dns-2.0.0.tar.gz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := List(context.Background(), server.Client(), server.URL+"/modules/", tt.index)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("List: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	byDir, err := NewRule("artifacts.internal/modules", "https://artifacts.internal/modules/index.json")
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+	rules := Rules{*byDir}
+	if got := rules.Match("artifacts.internal/modules"); got != byDir.Index {
+		t.Errorf("Match: got %q, want %q", got, byDir.Index)
+	}
+	if got := rules.Match("artifacts.internal/other"); got != "" {
+		t.Errorf("Match: got %q, want no index", got)
+	}
+}
@@ -0,0 +1,104 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// S3 lists objects in AWS S3 and S3-compatible buckets, using the default AWS credential chain.
+type S3 struct {
+	// Endpoint, if set, is used instead of the sources' endpoints (e.g. a local S3-compatible service).
+	Endpoint string
+	HTTP     *http.Client
+}
+
+// List returns the names of the objects directly under prefix (relative to prefix).
+// An empty endpoint addresses AWS S3 in the given region.
+func (c *S3) List(ctx context.Context, endpoint, region, bucket, prefix string) ([]string, error) {
+	if c.Endpoint != "" {
+		endpoint = c.Endpoint
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+		if c.HTTP != nil {
+			o.HTTPClient = c.HTTP
+		}
+	})
+	var out []string
+	pages := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list s3://%s/%s: %w", bucket, prefix, err)
+		}
+		for _, object := range page.Contents {
+			out = append(out, strings.TrimPrefix(aws.ToString(object.Key), prefix))
+		}
+	}
+	return out, nil
+}
+
+// GCS lists objects in Google Cloud Storage buckets, using the application default credentials
+// or, like Terraform, the access token in GOOGLE_OAUTH_ACCESS_TOKEN.
+type GCS struct {
+	// Endpoint, if set, overrides the storage API endpoint (e.g. for an emulator); requests are then unauthenticated.
+	Endpoint string
+}
+
+// List returns the names of the objects directly under prefix (relative to prefix).
+func (c *GCS) List(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var opts []option.ClientOption
+	switch token, ok := os.LookupEnv("GOOGLE_OAUTH_ACCESS_TOKEN"); {
+	case c.Endpoint != "":
+		opts = append(opts, option.WithEndpoint(c.Endpoint), option.WithoutAuthentication())
+	case ok:
+		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create gcs client: %w", err)
+	}
+	defer client.Close()
+	var out []string
+	objects := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: "/"})
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list gs://%s/%s: %w", bucket, prefix, err)
+		}
+		if attrs.Name == "" { // a "directory" (attrs.Prefix)
+			continue
+		}
+		out = append(out, strings.TrimPrefix(attrs.Name, prefix))
+	}
+	return out, nil
+}
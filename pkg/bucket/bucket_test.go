@@ -0,0 +1,83 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestS3List(t *testing.T) {
+	none := filepath.Join(t.TempDir(), "none")
+	t.Setenv("AWS_CONFIG_FILE", none)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", none)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	// a minimal S3-compatible endpoint serving two pages of ListObjectsV2 results
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/modules" || query.Get("list-type") != "2" || query.Get("prefix") != "vpc/" || query.Get("delimiter") != "/" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		if query.Get("continuation-token") == "" {
+			fmt.Fprint(w, `<ListBucketResult><Name>modules</Name><Prefix>vpc/</Prefix><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>`+
+				`<Contents><Key>vpc/vpc-1.4.0.zip</Key></Contents><Contents><Key>vpc/vpc-1.5.0.zip</Key></Contents>`+
+				`<CommonPrefixes><Prefix>vpc/old/</Prefix></CommonPrefixes></ListBucketResult>`)
+			return
+		}
+		fmt.Fprint(w, `<ListBucketResult><Name>modules</Name><Prefix>vpc/</Prefix><IsTruncated>false</IsTruncated>`+
+			`<Contents><Key>vpc/vpc-2.0.0.zip</Key></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	client := S3{Endpoint: server.URL}
+	got, err := client.List(context.Background(), "", "eu-west-1", "modules", "vpc/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"vpc-1.4.0.zip", "vpc-1.5.0.zip", "vpc-2.0.0.zip"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("List: (-want +got)\n%s", diff)
+	}
+}
+
+func TestGCSList(t *testing.T) {
+	// a minimal storage JSON API endpoint serving two pages of object listings
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/storage/v1/b/modules/o" || query.Get("prefix") != "vpc/" || query.Get("delimiter") != "/" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if query.Get("pageToken") == "" {
+			fmt.Fprint(w, `{"kind": "storage#objects", "nextPageToken": "next", "prefixes": ["vpc/old/"],
+				"items": [{"name": "vpc/vpc-1.4.0.zip", "bucket": "modules"}, {"name": "vpc/vpc-1.5.0.zip", "bucket": "modules"}]}`)
+			return
+		}
+		if query.Get("pageToken") != "next" {
+			http.Error(w, "unexpected page token "+query.Get("pageToken"), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"kind": "storage#objects", "items": [{"name": "vpc/vpc-2.0.0.zip", "bucket": "modules"}]}`)
+	}))
+	defer server.Close()
+
+	client := GCS{Endpoint: server.URL + "/storage/v1/"}
+	got, err := client.List(context.Background(), "modules", "vpc/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"vpc-1.4.0.zip", "vpc-1.5.0.zip", "vpc-2.0.0.zip"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("List: (-want +got)\n%s", diff)
+	}
+}
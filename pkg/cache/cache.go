@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Dir is a file-backed cache of version lists, one JSON file per entry.
+// Entries are written atomically (write to a temporary file, then rename),
+// so several processes may share a cache directory.
+type Dir struct {
+	Path string
+	// TTL is the time after which entries expire. Zero means entries never expire.
+	TTL time.Duration
+}
+
+// Entry is the version listing of a source.
+type Entry struct {
+	Key       string    `json:"key"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Versions are the raw version names (for git sources, the names of the remote's refs).
+	Versions []string `json:"versions"`
+	// Hashes maps git ref names to the commits they point at.
+	Hashes map[string]string `json:"hashes,omitempty"`
+	// Branches are the names of the git remote's branches.
+	Branches []string `json:"branches,omitempty"`
+}
+
+const fileExt = ".json"
+
+// DefaultPath is the cache directory below the user's cache directory.
+func DefaultPath(appName string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate user cache dir: %w", err)
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// Expired reports whether the entry is older than the given TTL.
+func (e Entry) Expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(e.FetchedAt) > ttl
+}
+
+// Get returns the unexpired entry for key, if there is one.
+// Unreadable entries are treated as missing.
+func (d Dir) Get(key string) (*Entry, bool) {
+	entry, err := d.read(d.path(key))
+	if err != nil || entry.Key != key || entry.Expired(d.TTL, time.Now()) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores the entry under its key, recording the current time as its fetch time.
+func (d Dir) Put(entry Entry) error {
+	if err := os.MkdirAll(d.Path, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	entry.FetchedAt = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	tmp, err := os.CreateTemp(d.Path, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path(entry.Key)); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// List returns all (including expired) entries.
+func (d Dir) List() ([]Entry, error) {
+	files, err := os.ReadDir(d.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+	out := make([]Entry, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileExt) {
+			continue
+		}
+		entry, err := d.read(filepath.Join(d.Path, f.Name()))
+		if err != nil {
+			continue
+		}
+		out = append(out, *entry)
+	}
+	return out, nil
+}
+
+// Remove deletes the entry for key.
+func (d Dir) Remove(key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clear deletes all entries.
+func (d Dir) Clear() error {
+	files, err := os.ReadDir(d.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read cache dir: %w", err)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileExt) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.Path, f.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove cache entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d Dir) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Path, hex.EncodeToString(sum[:])+fileExt)
+}
+
+func (d Dir) read(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("decode cache entry %q: %w", path, err)
+	}
+	return &entry, nil
+}
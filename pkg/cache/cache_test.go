@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDir(t *testing.T) {
+	dir := Dir{Path: t.TempDir(), TTL: time.Hour}
+	if _, ok := dir.Get("git:https://example.com/repo.git"); ok {
+		t.Fatalf("Get: unexpected entry in empty cache")
+	}
+
+	const key = "registry:hashicorp/consul/aws"
+	want := []string{"v0.7.3", "0.8.0"}
+	if err := dir.Put(Entry{Key: key, Versions: want}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	entry, ok := dir.Get(key)
+	if !ok {
+		t.Fatalf("Get: missing entry")
+	}
+	if diff := cmp.Diff(want, entry.Versions); diff != "" {
+		t.Errorf("Get: (-want +got)\n%s", diff)
+	}
+
+	expired := Dir{Path: dir.Path, TTL: time.Nanosecond}
+	time.Sleep(time.Millisecond)
+	if _, ok := expired.Get(key); ok {
+		t.Errorf("Get: expected entry to have expired")
+	}
+
+	entries, err := dir.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key != key {
+		t.Errorf("List: got %v, want a single entry for %q", entries, key)
+	}
+
+	if err := dir.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok := dir.Get(key); ok {
+		t.Errorf("Get: unexpected entry after Clear")
+	}
+}
+
+func TestDirConcurrentPut(t *testing.T) {
+	dir := Dir{Path: t.TempDir()}
+	const key = "git:https://example.com/repo.git"
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := dir.Put(Entry{Key: key, Versions: []string{fmt.Sprintf("1.0.%d", i)}}); err != nil {
+				t.Errorf("Put: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entry, ok := dir.Get(key)
+	if !ok {
+		t.Fatalf("Get: missing entry")
+	}
+	if len(entry.Versions) != 1 {
+		t.Errorf("Get: got %v, want a single version", entry.Versions)
+	}
+	files, err := os.ReadDir(dir.Path)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("ReadDir: got %d files, want 1 (no leftover temporary files)", len(files))
+	}
+}
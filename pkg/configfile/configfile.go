@@ -0,0 +1,118 @@
+package configfile
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/archive"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/rewrite"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+// File is the (HCL) configuration file, e.g.
+//
+//	tag_pattern {
+//	  remote = "github.com/acme/infra"
+//	  module = "vpc*"
+//	  regex  = "^vpc/v(?P<version>.+)$"
+//	}
+//
+//	archive_index {
+//	  remote = "artifacts.internal/modules"
+//	  url    = "https://artifacts.internal/modules/index.json"
+//	}
+//
+//	rewrite_git {
+//	  url        = "https://git-mirror.internal/github/"
+//	  instead_of = "https://github.com/"
+//	}
+type File struct {
+	TagPatterns      []TagPattern   `hcl:"tag_pattern,block"`
+	ArchiveIndexes   []ArchiveIndex `hcl:"archive_index,block"`
+	GitRewrites      []Rewrite      `hcl:"rewrite_git,block"`
+	RegistryRewrites []Rewrite      `hcl:"rewrite_registry,block"`
+}
+
+// ArchiveIndex is a JSON index (an array of archive URLs) listing the archives of matching HTTP sources.
+type ArchiveIndex struct {
+	// Remote is a glob matching the archive URL's host and directory.
+	Remote string `hcl:"remote,optional"`
+	URL    string `hcl:"url"`
+}
+
+// Rewrite redirects version lookups, like git's `url.<URL>.insteadOf = <InsteadOf>`.
+// For registries, both are hostnames.
+type Rewrite struct {
+	URL       string `hcl:"url"`
+	InsteadOf string `hcl:"instead_of"`
+}
+
+// TagPattern selects how versions are read from the tags of matching git sources.
+type TagPattern struct {
+	// Remote is a glob matching the remote's host and path.
+	Remote string `hcl:"remote,optional"`
+	// Module is a glob matching the module call's name.
+	Module string `hcl:"module,optional"`
+	// Regex is a regular expression with a named group (?P<version>...).
+	Regex  string `hcl:"regex,optional"`
+	Prefix string `hcl:"prefix,optional"`
+}
+
+func Load(path string) (*File, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("read config file %q: %w", path, diags)
+	}
+	var out File
+	if diags := gohcl.DecodeBody(file.Body, nil, &out); diags.HasErrors() {
+		return nil, fmt.Errorf("read config file %q: %w", path, diags)
+	}
+	return &out, nil
+}
+
+// RewriteRules returns the file's rewrite rules.
+func (f *File) RewriteRules() (rewrite.Rules, error) {
+	var out rewrite.Rules
+	for _, r := range f.GitRewrites {
+		rule, err := rewrite.NewRule(r.URL, r.InsteadOf)
+		if err != nil {
+			return rewrite.Rules{}, fmt.Errorf("rewrite_git: %w", err)
+		}
+		out.Git = append(out.Git, *rule)
+	}
+	for _, r := range f.RegistryRewrites {
+		rule, err := rewrite.NewRule(r.URL, r.InsteadOf)
+		if err != nil {
+			return rewrite.Rules{}, fmt.Errorf("rewrite_registry: %w", err)
+		}
+		out.Registry = append(out.Registry, *rule)
+	}
+	return out, nil
+}
+
+// ArchiveIndexRules compiles the file's archive indexes.
+func (f *File) ArchiveIndexRules() (archive.Rules, error) {
+	out := make(archive.Rules, 0, len(f.ArchiveIndexes))
+	for _, index := range f.ArchiveIndexes {
+		rule, err := archive.NewRule(index.Remote, index.URL)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rule)
+	}
+	return out, nil
+}
+
+// TagRules compiles the file's tag patterns.
+func (f *File) TagRules() (tags.Rules, error) {
+	out := make(tags.Rules, 0, len(f.TagPatterns))
+	for _, p := range f.TagPatterns {
+		rule, err := tags.NewRule(p.Remote, p.Module, p.Regex, p.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rule)
+	}
+	return out, nil
+}
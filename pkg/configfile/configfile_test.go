@@ -0,0 +1,60 @@
+package configfile
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/rewrite"
+)
+
+func TestLoad(t *testing.T) {
+	file, err := Load(filepath.Join("testdata", "config.hcl"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	rewrites, err := file.RewriteRules()
+	if err != nil {
+		t.Fatalf("RewriteRules: %v", err)
+	}
+	wantRewrites := rewrite.Rules{
+		Git:      []rewrite.Rule{{URL: "https://git-mirror.internal/github/", InsteadOf: "https://github.com/"}},
+		Registry: []rewrite.Rule{{URL: "registry-proxy.internal", InsteadOf: "registry.terraform.io"}},
+	}
+	if diff := cmp.Diff(wantRewrites, rewrites); diff != "" {
+		t.Errorf("RewriteRules: (-want +got)\n%s", diff)
+	}
+
+	tagRules, err := file.TagRules()
+	if err != nil {
+		t.Fatalf("TagRules: %v", err)
+	}
+	if len(tagRules) != 2 {
+		t.Fatalf("TagRules: got %d rules, want 2", len(tagRules))
+	}
+	if pattern := tagRules.Match("github.com/acme/infra", "vpc_main"); pattern == nil {
+		t.Errorf("TagRules: no pattern for the vpc module")
+	} else if version, ok := pattern.Version("vpc/v1.2.0"); !ok || version != "1.2.0" {
+		t.Errorf("TagRules: got version (%q, %v) of vpc/v1.2.0, want (%q, true)", version, ok, "1.2.0")
+	}
+
+	indexRules, err := file.ArchiveIndexRules()
+	if err != nil {
+		t.Fatalf("ArchiveIndexRules: %v", err)
+	}
+	if got, want := indexRules.Match("artifacts.internal/modules"), "https://artifacts.internal/modules/index.json"; got != want {
+		t.Errorf("ArchiveIndexRules: got index %q, want %q", got, want)
+	}
+}
+
+func TestRewriteRulesEmpty(t *testing.T) {
+	file, err := Load(filepath.Join("testdata", "empty_instead_of.hcl"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := file.RewriteRules(); !errors.Is(err, rewrite.ErrEmpty) {
+		t.Errorf("RewriteRules: got error %v, want %v", err, rewrite.ErrEmpty)
+	}
+}
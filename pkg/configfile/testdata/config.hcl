@@ -0,0 +1,24 @@
+tag_pattern {
+  remote = "github.com/acme/infra"
+  module = "vpc*"
+  regex  = "^vpc/v(?P<version>.+)$"
+}
+
+tag_pattern {
+  prefix = "release-"
+}
+
+archive_index {
+  remote = "artifacts.internal/modules"
+  url    = "https://artifacts.internal/modules/index.json"
+}
+
+rewrite_git {
+  url        = "https://git-mirror.internal/github/"
+  instead_of = "https://github.com/"
+}
+
+rewrite_registry {
+  url        = "registry-proxy.internal"
+  instead_of = "registry.terraform.io"
+}
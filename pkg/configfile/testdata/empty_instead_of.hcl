@@ -0,0 +1,4 @@
+rewrite_git {
+  url        = "https://git-mirror.internal/github/"
+  instead_of = ""
+}
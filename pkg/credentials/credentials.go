@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// Tokens are registry API tokens by (normalized) hostname.
+type Tokens map[string]string
+
+// Token returns the token for the given hostname (optionally with a port), if any.
+func (t Tokens) Token(hostname string) (string, bool) {
+	token, ok := t[Normalize(hostname)]
+	return token, ok
+}
+
+// Normalize lowercases the hostname and drops the default HTTPS port.
+func Normalize(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(hostname), ":443")
+}
+
+// Load reads the registry API tokens the Terraform CLI would use, in increasing order of precedence:
+// `credentials.tfrc.json` in the CLI configuration directory, `credentials "<HOST>" { token = ... }` blocks in the
+// CLI configuration file (TF_CLI_CONFIG_FILE or ~/.terraformrc), and TF_TOKEN_<HOST> environment variables.
+// ref.: https://developer.hashicorp.com/terraform/cli/config/config-file#credentials
+func Load() (Tokens, error) {
+	configDir, configFile := defaultPaths()
+	if path := os.Getenv("TF_CLI_CONFIG_FILE"); path != "" {
+		configFile = path
+	}
+	return load(filepath.Join(configDir, "credentials.tfrc.json"), configFile, os.Environ())
+}
+
+func defaultPaths() (configDir, configFile string) {
+	if runtime.GOOS == "windows" {
+		appData := os.Getenv("APPDATA")
+		return filepath.Join(appData, "terraform.d"), filepath.Join(appData, "terraform.rc")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".terraform.d"), filepath.Join(home, ".terraformrc")
+}
+
+func load(credentialsFile, configFile string, environ []string) (Tokens, error) {
+	out := make(Tokens)
+	if err := loadCredentialsFile(out, credentialsFile); err != nil {
+		return nil, err
+	}
+	if err := loadConfigFile(out, configFile); err != nil {
+		return nil, err
+	}
+	loadEnvironment(out, environ)
+	return out, nil
+}
+
+// loadCredentialsFile reads the JSON file written by `terraform login`.
+func loadCredentialsFile(out Tokens, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read credentials file: %w", err)
+	}
+	var file struct {
+		Credentials map[string]struct {
+			Token string `json:"token"`
+		} `json:"credentials"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("read credentials file %q: %w", path, err)
+	}
+	for host, credentials := range file.Credentials {
+		if credentials.Token != "" {
+			out[Normalize(host)] = credentials.Token
+		}
+	}
+	return nil
+}
+
+// loadConfigFile reads the `credentials` blocks of the CLI configuration file, ignoring its other settings.
+func loadConfigFile(out Tokens, path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	parser := hclparse.NewParser()
+	parse := parser.ParseHCLFile
+	if strings.HasSuffix(path, ".json") {
+		parse = parser.ParseJSONFile
+	}
+	file, diags := parse(path)
+	if diags.HasErrors() {
+		return fmt.Errorf("read CLI config file %q: %w", path, diags)
+	}
+	var config struct {
+		Credentials []struct {
+			Host   string   `hcl:"host,label"`
+			Token  string   `hcl:"token,optional"`
+			Remain hcl.Body `hcl:",remain"`
+		} `hcl:"credentials,block"`
+		Remain hcl.Body `hcl:",remain"`
+	}
+	if diags := gohcl.DecodeBody(file.Body, nil, &config); diags.HasErrors() {
+		return fmt.Errorf("read CLI config file %q: %w", path, diags)
+	}
+	for _, credentials := range config.Credentials {
+		if credentials.Token != "" {
+			out[Normalize(credentials.Host)] = credentials.Token
+		}
+	}
+	return nil
+}
+
+const envPrefix = "TF_TOKEN_"
+
+// loadEnvironment reads TF_TOKEN_<HOST> variables, where the host's dots are written as `_` and its dashes as `__`.
+func loadEnvironment(out Tokens, environ []string) {
+	for _, kv := range environ {
+		name, token, _ := strings.Cut(kv, "=")
+		host, ok := strings.CutPrefix(name, envPrefix)
+		if !ok || host == "" || token == "" {
+			continue
+		}
+		host = strings.ReplaceAll(strings.ReplaceAll(host, "__", "-"), "_", ".")
+		out[Normalize(host)] = token
+	}
+}
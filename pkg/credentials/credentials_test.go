@@ -0,0 +1,66 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials.tfrc.json")
+	configFile := filepath.Join(dir, ".terraformrc")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	writeFile(credentialsFile, `{"credentials": {"app.terraform.io": {"token": "from-login"}, "registry.internal": {"token": "from-login"}}}`)
+	writeFile(configFile, `
+plugin_cache_dir = "$HOME/.terraform.d/plugin-cache"
+
+credentials "Registry.Internal" {
+  token = "from-config"
+}
+
+provider_installation {
+  direct {}
+}
+`)
+	environ := []string{
+		"HOME=/home/test",
+		"TF_TOKEN_my__registry_example_com=from-env",
+		"TF_TOKEN_app_terraform_io=from-env",
+		"TF_TOKEN_empty_example_com=",
+	}
+
+	got, err := load(credentialsFile, configFile, environ)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := Tokens{
+		"app.terraform.io":        "from-env",
+		"registry.internal":       "from-config",
+		"my-registry.example.com": "from-env",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("load: (-want +got)\n%s", diff)
+	}
+	if token, ok := got.Token("REGISTRY.internal:443"); !ok || token != "from-config" {
+		t.Errorf("Token: got (%q, %v), want (%q, true)", token, ok, "from-config")
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	got, err := load(filepath.Join(dir, "missing.json"), filepath.Join(dir, "missing.rc"), nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("load: got %v, want no tokens", got)
+	}
+}
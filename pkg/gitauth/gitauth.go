@@ -0,0 +1,223 @@
+package gitauth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/bgentry/go-netrc/netrc"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	sshagent "github.com/xanzy/ssh-agent"
+	"golang.org/x/crypto/ssh"
+)
+
+// Resolver picks the credentials for git remotes by their host:
+//   - HTTP(S) remotes: host-scoped Tokens, then .netrc entries, then git's credential helpers;
+//   - SSH remotes: the SSH agent's keys and the given key files, with host keys checked against known_hosts.
+//
+// It is safe for concurrent use.
+type Resolver struct {
+	Tokens []Token
+	// Netrc is the path of the .netrc file to read ("": none).
+	Netrc string
+	// CredentialHelper asks git's configured credential helpers (`git credential fill`), without prompting.
+	CredentialHelper bool
+	// SSHAgent uses the keys held by the SSH agent (SSH_AUTH_SOCK, or Pageant on Windows).
+	SSHAgent bool
+	// SSHKeys are private key files to use; missing and passphrase-protected keys are skipped.
+	SSHKeys []string
+	// KnownHosts are the known_hosts files to check host keys against
+	// (default: SSH_KNOWN_HOSTS, or ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts).
+	KnownHosts []string
+
+	signersOnce sync.Once
+	signers     []ssh.Signer
+	signersErr  error
+}
+
+// Token is an access token for the hosts of a git service, sent as HTTP basic auth.
+type Token struct {
+	Hosts    []string
+	Username string
+	Token    string
+}
+
+var errNoSSHKeys = errors.New("no SSH keys available (from the SSH agent or key files)")
+
+// DefaultTokens returns the tokens given in the environment, each scoped to its service's hosts:
+// GITHUB_TOKEN (github.com and GH_HOST), GITLAB_TOKEN (gitlab.com and GITLAB_HOST), and BITBUCKET_TOKEN (bitbucket.org).
+func DefaultTokens(getenv func(string) string) []Token {
+	services := []struct {
+		host, hostEnv, tokenEnv, username string
+	}{
+		{"github.com", "GH_HOST", "GITHUB_TOKEN", "x-access-token"},
+		{"gitlab.com", "GITLAB_HOST", "GITLAB_TOKEN", "oauth2"},
+		{"bitbucket.org", "", "BITBUCKET_TOKEN", "x-token-auth"},
+	}
+	var out []Token
+	for _, service := range services {
+		token := getenv(service.tokenEnv)
+		if token == "" {
+			continue
+		}
+		hosts := []string{service.host}
+		if service.hostEnv != "" {
+			if host := getenv(service.hostEnv); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+		out = append(out, Token{Hosts: hosts, Username: service.username, Token: token})
+	}
+	return out
+}
+
+// Default returns a Resolver for the current user's environment.
+func Default() *Resolver {
+	home, _ := os.UserHomeDir()
+	netrcPath := os.Getenv("NETRC")
+	if netrcPath == "" {
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		netrcPath = filepath.Join(home, name)
+	}
+	var keys []string
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		keys = append(keys, filepath.Join(home, ".ssh", name))
+	}
+	return &Resolver{
+		Tokens:           DefaultTokens(os.Getenv),
+		Netrc:            netrcPath,
+		CredentialHelper: true,
+		SSHAgent:         os.Getenv("SSH_AUTH_SOCK") != "" || runtime.GOOS == "windows",
+		SSHKeys:          keys,
+	}
+}
+
+// Auth returns the credentials for the given remote URL, or nil to access it anonymously.
+func (r *Resolver) Auth(ctx context.Context, remote string) (transport.AuthMethod, error) {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, fmt.Errorf("parse remote url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.User != nil { // credentials given in the URL are used as they are
+			return nil, nil
+		}
+		return r.httpAuth(ctx, u), nil
+	case "ssh":
+		return r.sshAuth(u)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) httpAuth(ctx context.Context, u *url.URL) transport.AuthMethod {
+	host := u.Hostname()
+	for _, token := range r.Tokens {
+		for _, h := range token.Hosts {
+			if strings.EqualFold(h, host) || strings.EqualFold(h, u.Host) {
+				return &githttp.BasicAuth{Username: token.Username, Password: token.Token}
+			}
+		}
+	}
+	if r.Netrc != "" {
+		if n, err := netrc.ParseFile(r.Netrc); err == nil {
+			if machine := n.FindMachine(host); machine != nil && machine.Password != "" {
+				return &githttp.BasicAuth{Username: machine.Login, Password: machine.Password}
+			}
+		}
+	}
+	if r.CredentialHelper {
+		if username, password, ok := credentialFill(ctx, u); ok {
+			return &githttp.BasicAuth{Username: username, Password: password}
+		}
+	}
+	return nil
+}
+
+// credentialFill asks git's credential helpers for the credentials of the given URL.
+// ref.: https://git-scm.com/docs/git-credential
+func credentialFill(ctx context.Context, u *url.URL) (username, password string, ok bool) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", "", false
+	}
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+	return username, password, password != ""
+}
+
+func (r *Resolver) sshAuth(u *url.URL) (transport.AuthMethod, error) {
+	r.signersOnce.Do(r.loadSigners)
+	if r.signersErr != nil {
+		return nil, r.signersErr
+	}
+	if len(r.signers) == 0 {
+		return nil, errNoSSHKeys
+	}
+	user := u.User.Username()
+	if user == "" {
+		user = "git"
+	}
+	hostKeyCallback, err := gitssh.NewKnownHostsCallback(r.KnownHosts...)
+	if err != nil {
+		return nil, fmt.Errorf("read known_hosts: %w", err)
+	}
+	auth := &gitssh.PublicKeysCallback{
+		User:     user,
+		Callback: func() ([]ssh.Signer, error) { return r.signers, nil },
+	}
+	auth.HostKeyCallback = hostKeyCallback
+	return auth, nil
+}
+
+func (r *Resolver) loadSigners() {
+	if r.SSHAgent {
+		if agent, _, err := sshagent.New(); err == nil {
+			signers, err := agent.Signers()
+			if err != nil {
+				r.signersErr = fmt.Errorf("list SSH agent keys: %w", err)
+				return
+			}
+			r.signers = append(r.signers, signers...)
+		}
+	}
+	for _, path := range r.SSHKeys {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		if err != nil {
+			continue
+		}
+		r.signers = append(r.signers, signer)
+	}
+}
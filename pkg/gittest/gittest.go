@@ -0,0 +1,86 @@
+// Package gittest builds git repositories for tests.
+package gittest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Repo is a git repository in a temporary directory, starting out empty on the master branch.
+type Repo struct {
+	// URL is the repository's file:// URL.
+	URL string
+
+	t        testing.TB
+	repo     *git.Repository
+	worktree *git.Worktree
+	commits  int
+}
+
+// New creates an empty repository.
+func New(t testing.TB) *Repo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree: %v", err)
+	}
+	return &Repo{URL: "file://" + dir, t: t, repo: repo, worktree: worktree}
+}
+
+// Commit adds an empty commit authored and committed at the given time to the checked out branch.
+func (r *Repo) Commit(when time.Time) plumbing.Hash {
+	r.t.Helper()
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	r.commits++
+	hash, err := r.worktree.Commit(fmt.Sprintf("commit %d", r.commits), &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true})
+	if err != nil {
+		r.t.Fatalf("Commit: %v", err)
+	}
+	return hash
+}
+
+// Tag creates a lightweight tag pointing at the given commit.
+func (r *Repo) Tag(name string, commit plumbing.Hash) {
+	r.t.Helper()
+	if _, err := r.repo.CreateTag(name, commit, nil); err != nil {
+		r.t.Fatalf("CreateTag: %v", err)
+	}
+}
+
+// AnnotatedTag creates an annotated tag with the given tagger date pointing at the given commit,
+// and returns the hash of the tag object.
+func (r *Repo) AnnotatedTag(name string, commit plumbing.Hash, when time.Time) plumbing.Hash {
+	r.t.Helper()
+	tagger := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	ref, err := r.repo.CreateTag(name, commit, &git.CreateTagOptions{Tagger: tagger, Message: name})
+	if err != nil {
+		r.t.Fatalf("CreateTag: %v", err)
+	}
+	return ref.Hash()
+}
+
+// Release adds a commit at the given time and tags it with a lightweight tag.
+func (r *Repo) Release(tag string, when time.Time) plumbing.Hash {
+	r.t.Helper()
+	commit := r.Commit(when)
+	r.Tag(tag, commit)
+	return commit
+}
+
+// Branch creates the given branch at the given commit and checks it out.
+func (r *Repo) Branch(name string, commit plumbing.Hash) {
+	r.t.Helper()
+	if err := r.worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Hash: commit, Create: true}); err != nil {
+		r.t.Fatalf("Checkout: %v", err)
+	}
+}
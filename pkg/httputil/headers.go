@@ -2,13 +2,17 @@ package httputil
 
 import "net/http"
 
+// AddHeadersRoundtripper sets the given headers on each request (replacing any values already set)
+// before passing it on to Nested. The request itself is left unmodified.
 type AddHeadersRoundtripper struct {
 	Headers http.Header
 	Nested  http.RoundTripper
 }
 
 func (h AddHeadersRoundtripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
 	for k, vs := range h.Headers {
+		r.Header.Del(k)
 		for _, v := range vs {
 			r.Header.Add(k, v)
 		}
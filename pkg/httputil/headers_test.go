@@ -0,0 +1,43 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddHeadersRoundtripper(t *testing.T) {
+	var got []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, http.Header{"Authorization": r.Header.Values("Authorization"), "X-Other": r.Header.Values("X-Other")})
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: AddHeadersRoundtripper{
+		Headers: http.Header{"Authorization": {"Bearer secret"}},
+		Nested:  http.DefaultTransport,
+	}}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer other")
+	req.Header.Set("X-Other", "kept")
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	header := http.Header{"Authorization": {"Bearer secret"}, "X-Other": {"kept"}}
+	if diff := cmp.Diff([]http.Header{header, header}, got); diff != "" {
+		t.Errorf("sent headers: (-want +got)\n%s", diff)
+	}
+	if v := req.Header.Get("Authorization"); v != "Bearer other" {
+		t.Errorf("request modified: got Authorization %q", v)
+	}
+}
@@ -0,0 +1,52 @@
+package modulecall
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/go-version"
+)
+
+// Constraints decide which versions satisfy a module call's version constraints.
+type Constraints interface {
+	Check(*semver.Version) bool
+}
+
+// TerraformConstraints are version constraints evaluated like Terraform does:
+// `~> 1.2` allows `>= 1.2, < 2.0`, and pre-release versions only match
+// constraints that name a pre-release of the same version.
+type TerraformConstraints struct {
+	constraints version.Constraints
+}
+
+func NewTerraformConstraints(raw string) (*TerraformConstraints, error) {
+	constraints, err := version.NewConstraint(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &TerraformConstraints{constraints: constraints}, nil
+}
+
+func (c *TerraformConstraints) Check(v *semver.Version) bool {
+	tfVersion, err := version.NewVersion(v.Original())
+	if err != nil {
+		return false
+	}
+	return c.constraints.Check(tfVersion)
+}
+
+func parseTerraformConstraints(raw string) (Constraints, error) {
+	constraints, err := NewTerraformConstraints(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse constraint %q: %w", raw, err)
+	}
+	return constraints, nil
+}
+
+func parseMastermindsConstraints(raw string) (Constraints, error) {
+	constraints, err := semver.NewConstraint(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse constraint %q: %w", raw, err)
+	}
+	return constraints, nil
+}
@@ -0,0 +1,129 @@
+package modulecall
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+func TestConstraintsTerraformVsMasterminds(t *testing.T) {
+	tests := []struct {
+		constraint      string
+		version         string
+		wantTerraform   bool
+		wantMasterminds bool
+	}{
+		{"~> 1.2", "1.2.0", true, true},
+		{"~> 1.2", "1.9.0", true, false},
+		{"~> 1.2", "2.0.0", false, false},
+		{"~> 1.2.0", "1.2.9", true, true},
+		{"~> 1.2.0", "1.3.0", false, false},
+		{"~> 0.12", "0.13.1", true, false},
+		{"~> 1.2", "1.3.0-beta1", false, false},
+		{">= 1.0.0-beta1", "1.2.0-rc1", false, true},
+		{">= 1.0.0-beta1", "1.0.0-beta2", true, true},
+		{"= 1.2.0-beta", "1.2.0-beta", true, true},
+		{">= 1.0, < 2.0", "1.5.0", true, true},
+		{">= 1.0, < 2.0", "2.0.0", false, false},
+		{"1.2.3", "1.2.3", true, true},
+		{"1.2.3", "v1.2.3", true, true},
+		{"1.2.3", "1.2.4", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			v, err := semver.NewVersion(tt.version)
+			if err != nil {
+				t.Fatalf("NewVersion: %v", err)
+			}
+			terraform, err := parseTerraformConstraints(tt.constraint)
+			if err != nil {
+				t.Fatalf("parseTerraformConstraints: %v", err)
+			}
+			if got := terraform.Check(v); got != tt.wantTerraform {
+				t.Errorf("terraform: got %v, want %v", got, tt.wantTerraform)
+			}
+			masterminds, err := parseMastermindsConstraints(tt.constraint)
+			if err != nil {
+				t.Fatalf("parseMastermindsConstraints: %v", err)
+			}
+			if got := masterminds.Check(v); got != tt.wantMasterminds {
+				t.Errorf("masterminds: got %v, want %v", got, tt.wantMasterminds)
+			}
+		})
+	}
+}
+
+func TestParseGitConstraints(t *testing.T) {
+	raw := tfconfig.ModuleCall{
+		Source:  "github.com/hashicorp/terraform-aws-consul?ref=v0.1.0",
+		Version: "~0.1.0",
+	}
+	if _, err := Parse(raw, Options{}); err == nil {
+		t.Errorf("Parse: expected an error for a constraint that Terraform rejects")
+	}
+	parsed, err := Parse(raw, Options{MastermindsGitConstraints: true})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := parsed.Constraints.(*semver.Constraints); !ok {
+		t.Errorf("Parse: got %T, want Masterminds constraints", parsed.Constraints)
+	}
+}
+
+func TestParseGitTagPattern(t *testing.T) {
+	rule, err := tags.NewRule("github.com/acme/infra", "", `^vpc/v(?P<version>.+)$`, "")
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+	raw := tfconfig.ModuleCall{
+		Name:   "vpc",
+		Source: "git::https://github.com/acme/infra.git//modules/vpc?ref=vpc/v1.4.0",
+	}
+	parsed, err := Parse(raw, Options{TagPatterns: tags.Rules{*rule}})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.Source.Git.TagPattern != rule.Pattern {
+		t.Errorf("Parse: tag pattern not applied")
+	}
+	if parsed.Version == nil || parsed.Version.String() != "1.4.0" {
+		t.Errorf("Parse: got version %v, want 1.4.0", parsed.Version)
+	}
+	if parsed.VersionString != "vpc/v1.4.0" {
+		t.Errorf("Parse: got version string %q, want %q", parsed.VersionString, "vpc/v1.4.0")
+	}
+}
+
+func TestParseBucketKeyPattern(t *testing.T) {
+	rule, err := tags.NewRule("bucket/modules/*", "", `^terraform-vpc_(?P<version>.+)_all\.zip$`, "")
+	if err != nil {
+		t.Fatalf("NewRule: %v", err)
+	}
+	tests := []struct {
+		source      string
+		wantVersion string
+	}{
+		{"s3::https://s3.amazonaws.com/bucket/vpc/vpc-1.4.0.zip", "1.4.0"},
+		{"s3::https://s3.amazonaws.com/bucket/modules/vpc/vpc-1.4.0.zip", ""},
+		{"s3::https://s3.amazonaws.com/bucket/modules/vpc/terraform-vpc_1.4.0_all.zip", "1.4.0"},
+		{"s3::https://s3.amazonaws.com/bucket/other/vpc.zip", ""},
+	}
+	for _, tt := range tests {
+		parsed, err := Parse(tfconfig.ModuleCall{Name: "vpc", Source: tt.source}, Options{TagPatterns: tags.Rules{*rule}})
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.source, err)
+		}
+		var got string
+		if parsed.Version != nil {
+			got = parsed.Version.String()
+		}
+		if got != tt.wantVersion {
+			t.Errorf("Parse(%q): got version %q, want %q", tt.source, got, tt.wantVersion)
+		}
+		if want := parsed.Source.Bucket.Object(); parsed.VersionString != want {
+			t.Errorf("Parse(%q): got version string %q, want %q", tt.source, parsed.VersionString, want)
+		}
+	}
+}
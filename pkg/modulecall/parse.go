@@ -5,19 +5,33 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/archive"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/source"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
 )
 
 type Parsed struct {
 	Source            *source.Source
 	Version           *semver.Version
 	VersionString     string
-	Constraints       *semver.Constraints
+	Constraints       Constraints
 	ConstraintsString string
 	Raw               tfconfig.ModuleCall
 }
 
-func Parse(raw tfconfig.ModuleCall) (*Parsed, error) {
+type Options struct {
+	// MastermindsGitConstraints evaluates the version constraints of git sources
+	// with Masterminds semver's rules (e.g. `~1.2`, `^1.2`) instead of Terraform's.
+	MastermindsGitConstraints bool
+	// TagPatterns select how versions are read from the tags of git sources,
+	// and from the archive names of bucket and HTTP sources (which otherwise follow the name of the referenced archive).
+	TagPatterns tags.Rules
+	// ArchiveIndexes select the JSON indexes listing the archives of HTTP sources
+	// (without one, archives are read from the HTML directory listing).
+	ArchiveIndexes archive.Rules
+}
+
+func Parse(raw tfconfig.ModuleCall, opts Options) (*Parsed, error) {
 	src, err := source.Parse(raw.Source)
 	if err != nil {
 		return nil, fmt.Errorf("parse module call source: %w", err)
@@ -25,10 +39,13 @@ func Parse(raw tfconfig.ModuleCall) (*Parsed, error) {
 	out := Parsed{Source: src, Raw: raw}
 	switch {
 	case src.Git != nil:
+		src.Git.TagPattern = opts.TagPatterns.Match(src.Git.HostPath(), raw.Name)
 		if ref := src.Git.RefValue; ref != nil {
-			version, err := semver.NewVersion(*ref)
-			if err == nil {
-				out.Version = version
+			if versionString, ok := src.Git.TagPattern.Version(*ref); ok {
+				version, err := semver.NewVersion(versionString)
+				if err == nil {
+					out.Version = version
+				}
 			}
 			out.VersionString = *ref
 		}
@@ -36,27 +53,128 @@ func Parse(raw tfconfig.ModuleCall) (*Parsed, error) {
 			return &out, nil
 		}
 		// this adds (non-terraform-standard..) support for version constraints to Git sources
-		constraints, err := semver.NewConstraint(raw.Version)
+		parseConstraints := parseTerraformConstraints
+		if opts.MastermindsGitConstraints {
+			parseConstraints = parseMastermindsConstraints
+		}
+		constraints, err := parseConstraints(raw.Version)
 		if err != nil {
-			return nil, fmt.Errorf("parse constraint %q: %w", raw.Version, err)
+			return nil, err
 		}
 		out.Constraints = constraints
 		out.ConstraintsString = raw.Version
 	case src.Registry != nil:
+		if err := out.parseRegistryVersion(raw.Version); err != nil {
+			return nil, err
+		}
+	case src.Bucket != nil:
+		bucket := src.Bucket
+		bucket.KeyPattern = namePattern(opts.TagPatterns, bucket.HostPath(), raw.Name, bucket.Object())
+		out.parseArchiveVersion(bucket.KeyPattern, bucket.Object())
+		if err := out.parseArchiveConstraints(raw.Version); err != nil {
+			return nil, err
+		}
+	case src.HTTP != nil:
+		http := src.HTTP
+		http.NamePattern = namePattern(opts.TagPatterns, http.HostPath(), raw.Name, http.Object())
+		http.Index = opts.ArchiveIndexes.Match(http.HostPath())
+		out.parseArchiveVersion(http.NamePattern, http.Object())
+		if err := out.parseArchiveConstraints(raw.Version); err != nil {
+			return nil, err
+		}
+	case src.OCI != nil:
+		switch oci := src.OCI; {
+		case oci.Tag != nil:
+			version, err := semver.NewVersion(*oci.Tag)
+			if err == nil {
+				out.Version = version
+			}
+			out.VersionString = *oci.Tag
+		case oci.Digest != nil:
+			out.VersionString = *oci.Digest
+		}
 		if raw.Version == "" {
 			return &out, nil
 		}
-		version, err := semver.NewVersion(raw.Version)
-		if err == nil { // interpret a single-version constraint as a pinned version
-			out.Version = version
-			out.VersionString = raw.Version
-		}
-		constraints, err := semver.NewConstraint(raw.Version)
+		constraints, err := parseTerraformConstraints(raw.Version)
 		if err != nil {
-			return nil, fmt.Errorf("parse constraint %q: %w", raw.Version, err)
+			return nil, err
 		}
 		out.Constraints = constraints
 		out.ConstraintsString = raw.Version
 	}
 	return &out, nil
 }
+
+// ParseProvider parses a provider requirement, given in the form of a module call
+// with the provider's source address as Source and its version constraints as Version.
+func ParseProvider(raw tfconfig.ModuleCall) (*Parsed, error) {
+	src, err := source.ParseProvider(raw.Source)
+	if err != nil {
+		return nil, err
+	}
+	out := Parsed{Source: src, Raw: raw}
+	if err := out.parseRegistryVersion(raw.Version); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ParseCore parses a Terraform core version requirement, given in the form of a
+// module call with the (joined) required_version constraints as Version.
+func ParseCore(raw tfconfig.ModuleCall) (*Parsed, error) {
+	out := Parsed{Source: &source.Source{Core: &source.Core{Product: "terraform"}}, Raw: raw}
+	if err := out.parseRegistryVersion(raw.Version); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// namePattern returns the configured pattern for an archive's (host and) directory, or the one inferred from its name.
+func namePattern(rules tags.Rules, remote, module, name string) *tags.Pattern {
+	if pattern := rules.Match(remote, module); pattern != nil {
+		return pattern
+	}
+	return tags.Infer(name)
+}
+
+func (p *Parsed) parseArchiveVersion(pattern *tags.Pattern, name string) {
+	if versionString, ok := pattern.Version(name); ok {
+		version, err := semver.NewVersion(versionString)
+		if err == nil {
+			p.Version = version
+		}
+	}
+	p.VersionString = name
+}
+
+func (p *Parsed) parseArchiveConstraints(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	constraints, err := parseTerraformConstraints(raw)
+	if err != nil {
+		return err
+	}
+	p.Constraints = constraints
+	p.ConstraintsString = raw
+	return nil
+}
+
+func (p *Parsed) parseRegistryVersion(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	version, err := semver.NewVersion(raw)
+	if err == nil { // interpret a single-version constraint as a pinned version
+		p.Version = version
+		p.VersionString = raw
+	}
+	constraints, err := parseTerraformConstraints(raw)
+	if err != nil {
+		return err
+	}
+	p.Constraints = constraints
+	p.ConstraintsString = raw
+	return nil
+}
@@ -0,0 +1,152 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Client lists tags from OCI distribution registries.
+type Client struct {
+	HTTP *http.Client
+}
+
+var (
+	errUnsupportedChallenge = errors.New("unsupported authentication challenge")
+	errNoToken              = errors.New("no token in token response")
+)
+
+// ListTags lists the tags of the given repository, following `Link` header pagination.
+// Registries that require a bearer token (announced in a `WWW-Authenticate` challenge) are sent an anonymously obtained one.
+// ref.: https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-tags
+func (c *Client) ListTags(ctx context.Context, registry, repository string) ([]string, error) {
+	next := &url.URL{Scheme: "https", Host: registry, Path: "/v2/" + repository + "/tags/list"}
+	var (
+		out   []string
+		token string
+	)
+	for next != nil {
+		resp, err := c.get(ctx, next, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			token, err = c.token(ctx, challenge)
+			if err != nil {
+				return nil, fmt.Errorf("authenticate to %q: %w", registry, err)
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %q: %s", next, resp.Status)
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode tag list: %w", err)
+		}
+		out = append(out, page.Tags...)
+		next, err = nextPage(next, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) get(ctx context.Context, u *url.URL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", u, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", u, err)
+	}
+	return resp, nil
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// token obtains a bearer token as requested by the given `WWW-Authenticate` challenge.
+// ref.: https://distribution.github.io/distribution/spec/auth/token/
+func (c *Client) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("%w: %q", errUnsupportedChallenge, challenge)
+	}
+	values := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("%w: %q", errUnsupportedChallenge, challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value := values[key]; value != "" {
+			query.Set(key, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+	resp, err := c.get(ctx, realm, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %q: %s", realm, resp.Status)
+	}
+	var response struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	switch {
+	case response.Token != "":
+		return response.Token, nil
+	case response.AccessToken != "":
+		return response.AccessToken, nil
+	}
+	return "", errNoToken
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextPage resolves the `rel="next"` target of a `Link` header against the current page's URL.
+func nextPage(current *url.URL, link string) (*url.URL, error) {
+	match := linkNext.FindStringSubmatch(link)
+	if match == nil {
+		return nil, nil
+	}
+	target, err := url.Parse(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("parse Link header %q: %w", link, err)
+	}
+	return current.ResolveReference(target), nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
@@ -0,0 +1,65 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientListTags(t *testing.T) {
+	const token = "secret-token"
+	var realm string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("scope"), "repository:modules/vpc:pull"; got != want {
+			t.Errorf("token scope: got %q, want %q", got, want)
+		}
+		fmt.Fprintf(w, `{"token": %q}`, token)
+	})
+	mux.HandleFunc("/v2/modules/vpc/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="registry.test",scope="repository:modules/vpc:pull"`, realm))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("last") {
+		case "":
+			w.Header().Set("Link", `</v2/modules/vpc/tags/list?n=2&last=1.1.0>; rel="next"`)
+			_, _ = w.Write([]byte(`{"name": "modules/vpc", "tags": ["1.0.0", "1.1.0"]}`))
+		case "1.1.0":
+			_, _ = w.Write([]byte(`{"name": "modules/vpc", "tags": ["2.0.0", "latest"]}`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.RawQuery)
+		}
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	realm = server.URL + "/token"
+
+	client := Client{HTTP: server.Client()}
+	got, err := client.ListTags(context.Background(), strings.TrimPrefix(server.URL, "https://"), "modules/vpc")
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if diff := cmp.Diff([]string{"1.0.0", "1.1.0", "2.0.0", "latest"}, got); diff != "" {
+		t.Errorf("ListTags: (-want +got)\n%s", diff)
+	}
+}
+
+func TestClientListTagsUnauthorized(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := Client{HTTP: server.Client()}
+	if _, err := client.ListTags(context.Background(), strings.TrimPrefix(server.URL, "https://"), "modules/vpc"); err == nil {
+		t.Errorf("ListTags: expected an error for an unsupported challenge")
+	}
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+type CacheEntries []CacheEntry
+
+func (c CacheEntries) Len() int           { return len(c) }
+func (c CacheEntries) Less(i, j int) bool { return c[i].Key < c[j].Key }
+func (c CacheEntries) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Expired   bool      `json:"expired,omitempty"`
+	Versions  []string  `json:"versions,omitempty"`
+}
+
+func (c CacheEntries) Write(w io.Writer, as Format) error {
+	switch as {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(c)
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, item := range c {
+			if err := enc.Encode(item); err != nil {
+				return fmt.Errorf("encode json: %w", err)
+			}
+		}
+		return nil
+	case FormatMarkdown, FormatMarkdownWide:
+		return c.WriteMarkdown(w)
+	}
+	return nil
+}
+
+func (c CacheEntries) WriteMarkdown(w io.Writer) error {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Key", "Versions", "Fetched", "Expired?"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	rows := make([][]string, 0, len(c))
+	for _, item := range c {
+		expired := ""
+		if item.Expired {
+			expired = "Y"
+		}
+		row := []string{item.Key, strconv.Itoa(len(item.Versions)), item.FetchedAt.Format(time.RFC3339), expired}
+		rows = append(rows, row)
+	}
+	table.AppendBulk(rows)
+	table.Render()
+	return nil
+}
@@ -18,16 +18,26 @@ func (m Modules) Less(i, j int) bool { return m[i].SortKey() < m[j].SortKey() }
 func (m Modules) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
 
 type Module struct {
-	Path              string `json:"path,omitempty"`
-	Name              string `json:"name,omitempty"`
-	Type              string `json:"type,omitempty"`
-	Source            string `json:"source,omitempty"`
-	VersionConstraint string `json:"constraint,omitempty"`
-	Version           string `json:"version,omitempty"`
+	Kind              string   `json:"kind,omitempty"`
+	Root              string   `json:"root,omitempty"`
+	Path              string   `json:"path,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Parents           []string `json:"parents,omitempty"`
+	Type              string   `json:"type,omitempty"`
+	Source            string   `json:"source,omitempty"`
+	Subdir            string   `json:"subdir,omitempty"`
+	VersionConstraint string   `json:"constraint,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	Installed         string   `json:"installed,omitempty"`
+}
+
+// QualifiedName is the module call's name prefixed with the names of its parent module calls.
+func (m *Module) QualifiedName() string {
+	return qualifiedName(m.Parents, m.Name)
 }
 
 func (m *Module) SortKey() string {
-	return fmt.Sprint(m.Path, m.Name)
+	return fmt.Sprint(m.Root, m.Path, m.QualifiedName())
 }
 
 func (m Modules) Write(w io.Writer, as Format) error {
@@ -65,12 +75,12 @@ func (m Modules) WriteJSON(w io.Writer) error {
 
 func (m Modules) WriteMarkdownWide(w io.Writer) error {
 	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Type", "Name", "Constraint", "Version", "Source", "Path"})
+	table.SetHeader([]string{"Kind", "Type", "Name", "Constraint", "Version", "Source", "Path"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	rows := make([][]string, 0, len(m))
 	for _, item := range m {
-		row := []string{item.Type, item.Name, item.VersionConstraint, item.Version, item.Source, item.Path}
+		row := []string{item.Kind, item.Type, item.QualifiedName(), item.VersionConstraint, item.Version, item.Source, item.Path}
 		rows = append(rows, row)
 	}
 	table.AppendBulk(rows)
@@ -78,15 +88,22 @@ func (m Modules) WriteMarkdownWide(w io.Writer) error {
 	return nil
 }
 
+// WriteMarkdown writes a table of the modules, with a Root column if they were found under more than one scanned path,
+// and a Kind column if they include more than module calls.
 func (m Modules) WriteMarkdown(w io.Writer) error {
+	roots, kinds := make([]string, len(m)), make([]string, len(m))
+	for i, item := range m {
+		roots[i], kinds[i] = item.Root, item.Kind
+	}
+	showRoot, showKind := multipleRoots(roots), otherKinds(kinds)
 	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Type", "Name", "Constraint", "Version", "Source"})
+	table.SetHeader(withColumn(showRoot, "Root", withColumn(showKind, "Kind", []string{"Type", "Name", "Constraint", "Version", "Source"})))
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	rows := make([][]string, 0, len(m))
 	for _, item := range m {
-		row := []string{item.Type, item.Name, item.VersionConstraint, item.Version, item.Source}
-		rows = append(rows, row)
+		row := []string{item.Type, item.QualifiedName(), item.VersionConstraint, item.Version, item.Source}
+		rows = append(rows, withColumn(showRoot, item.Root, withColumn(showKind, item.Kind, row)))
 	}
 	sort.Slice(rows, func(i, j int) bool {
 		return fmt.Sprint(rows[i]) > fmt.Sprint(rows[j])
@@ -102,7 +119,7 @@ func (m Modules) WriteJUnit(w io.Writer) error {
 	failures := 0
 	for i, module := range m {
 		testCase := junit.JUnitTestCase{
-			Name:      module.Name,
+			Name:      module.QualifiedName(),
 			Classname: module.Path,
 			Time:      "0",
 		}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestModulesWriteMarkdownColumns(t *testing.T) {
+	tests := []struct {
+		name               string
+		modules            Modules
+		wantRoot, wantKind bool
+	}{
+		{
+			name:    "single root",
+			modules: Modules{{Kind: "module", Root: "live", Name: "vpc"}, {Kind: "module", Root: "live", Name: "dns"}},
+		},
+		{
+			name:     "multiple roots",
+			modules:  Modules{{Kind: "module", Root: "live/dev", Name: "vpc"}, {Kind: "module", Root: "live/prod", Name: "vpc"}},
+			wantRoot: true,
+		},
+		{
+			name:     "terragrunt",
+			modules:  Modules{{Kind: "module", Root: "live", Name: "vpc"}, {Kind: "terragrunt", Root: "live", Name: "prod"}},
+			wantKind: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.modules.WriteMarkdown(&buf); err != nil {
+				t.Fatalf("WriteMarkdown: %v", err)
+			}
+			header, _, _ := strings.Cut(buf.String(), "\n")
+			if got := strings.Contains(header, "ROOT"); got != tt.wantRoot {
+				t.Errorf("WriteMarkdown: Root column shown: %v, want %v\n%s", got, tt.wantRoot, buf.String())
+			}
+			if got := strings.Contains(header, "KIND"); got != tt.wantKind {
+				t.Errorf("WriteMarkdown: Kind column shown: %v, want %v\n%s", got, tt.wantKind, buf.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package output
 
-import "sort"
+import (
+	"sort"
+	"strings"
+)
 
 type format string
 type Format format
@@ -35,3 +38,38 @@ func ParseFormatName(s string) (Format, bool) {
 	f, ok := formats[s]
 	return f, ok
 }
+
+func qualifiedName(parents []string, name string) string {
+	if len(parents) == 0 {
+		return name
+	}
+	return strings.Join(parents, ".") + "." + name
+}
+
+// withColumn prefixes a markdown table row with the value of an optional column, if it is shown.
+func withColumn(show bool, value string, row []string) []string {
+	if !show {
+		return row
+	}
+	return append([]string{value}, row...)
+}
+
+// multipleRoots reports whether the given roots differ.
+func multipleRoots(roots []string) bool {
+	for _, root := range roots {
+		if root != roots[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// otherKinds reports whether any of the given kinds is not a module call.
+func otherKinds(kinds []string) bool {
+	for _, kind := range kinds {
+		if kind != "" && kind != "module" {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	junit "github.com/jstemmer/go-junit-report/formatter"
 	"github.com/olekukonko/tablewriter"
@@ -20,19 +21,133 @@ func (u Updates) Less(i, j int) bool { return u[i].SortKey() < u[j].SortKey() }
 func (u Updates) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
 
 type Update struct {
-	Path              string `json:"path,omitempty"`
-	Name              string `json:"name,omitempty"`
-	Source            string `json:"source,omitempty"`
-	VersionConstraint string `json:"constraint,omitempty"`
-	Version           string `json:"version,omitempty"`
-	LatestMatching    string `json:"latestMatching,omitempty"`
-	LatestOverall     string `json:"latestOverall,omitempty"`
+	Kind              string   `json:"kind,omitempty"`
+	Root              string   `json:"root,omitempty"`
+	Path              string   `json:"path,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Parents           []string `json:"parents,omitempty"`
+	Source            string   `json:"source,omitempty"`
+	Subdir            string   `json:"subdir,omitempty"`
+	VersionConstraint string   `json:"constraint,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	// InferredVersions are the version tags pointing at the commit given as Version.
+	InferredVersions []string `json:"inferredVersions,omitempty"`
+	LatestMatching   string   `json:"latestMatching,omitempty"`
+	LatestOverall    string   `json:"latestOverall,omitempty"`
+	// PendingCooldown are newer versions held back because they were released too recently (see -min-age).
+	PendingCooldown []string `json:"pendingCooldown,omitempty"`
+	// CooldownError, if set, is why the release dates of some newer versions couldn't be checked for -min-age;
+	// those versions are not held back.
+	CooldownError     string `json:"cooldownError,omitempty"`
 	MatchingUpdate    bool   `json:"matchingUpdate,omitempty"`
 	NonMatchingUpdate bool   `json:"nonMatchingUpdate,omitempty"`
+	Installed         string `json:"installed,omitempty"`
+	InstalledUpdate   bool   `json:"installedUpdate,omitempty"`
+	// Status flags module calls that need attention regardless of updates (e.g. StatusUnpinnedBranch).
+	Status         string `json:"status,omitempty"`
+	RecommendedPin string `json:"recommendedPin,omitempty"`
+	// CommitsAhead and CommitsBehind compare a pinned branch with RecommendedPin, if known.
+	CommitsAhead  *int `json:"commitsAhead,omitempty"`
+	CommitsBehind *int `json:"commitsBehind,omitempty"`
+	// PublishedAt and LatestPublishedAt are the release dates of Version and LatestOverall
+	// (from registry metadata or git tag dates), if known.
+	PublishedAt       *time.Time `json:"publishedAt,omitempty"`
+	LatestPublishedAt *time.Time `json:"latestPublishedAt,omitempty"`
+	// Registry metadata: details of the module.
+	Verified    bool         `json:"verified,omitempty"`
+	Downloads   int          `json:"downloads,omitempty"`
+	Repository  string       `json:"repository,omitempty"`
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
+}
+
+// Deprecation describes why the current version of a module is deprecated.
+type Deprecation struct {
+	Reason string `json:"reason,omitempty"`
+	Link   string `json:"link,omitempty"`
+}
+
+// StatusUnpinnedBranch marks git sources whose ref is a branch rather than a version.
+const StatusUnpinnedBranch = "unpinned branch ref"
+
+// QualifiedName is the module call's name prefixed with the names of its parent module calls.
+func (u *Update) QualifiedName() string {
+	return qualifiedName(u.Parents, u.Name)
+}
+
+// marker summarizes the update state for the "Update?" table column.
+func (u *Update) marker() string {
+	switch {
+	case u.Status == StatusUnpinnedBranch:
+		return "B"
+	case u.MatchingUpdate:
+		return "Y"
+	case u.InstalledUpdate:
+		return "I"
+	case u.Deprecation != nil:
+		return "D"
+	case u.NonMatchingUpdate:
+		return "(Y)"
+	case u.Version == "":
+		return "?"
+	}
+	return ""
+}
+
+// versionCell is the current version for the "Version" table column.
+func (u *Update) versionCell() string {
+	var notes []string
+	switch {
+	case u.Status == StatusUnpinnedBranch && u.CommitsAhead != nil && u.CommitsBehind != nil:
+		notes = append(notes, fmt.Sprintf("%s: %d ahead, %d behind %s", u.Status, *u.CommitsAhead, *u.CommitsBehind, u.RecommendedPin))
+	case u.Status != "":
+		notes = append(notes, u.Status)
+	case len(u.InferredVersions) > 0:
+		notes = append(notes, strings.Join(u.InferredVersions, ", "))
+	}
+	if u.PublishedAt != nil {
+		notes = append(notes, releasedAgo(*u.PublishedAt))
+	}
+	if u.Deprecation != nil {
+		notes = append(notes, "deprecated")
+	}
+	return withNotes(u.Version, notes)
+}
+
+// latestCell is the latest version for the "Latest" table column.
+func (u *Update) latestCell() string {
+	var notes []string
+	if u.LatestPublishedAt != nil && u.LatestOverall != "" {
+		notes = append(notes, releasedAgo(*u.LatestPublishedAt))
+	}
+	if len(u.PendingCooldown) > 0 {
+		notes = append(notes, "pending cooldown: "+strings.Join(u.PendingCooldown, ", "))
+	}
+	return withNotes(u.LatestOverall, notes)
+}
+
+func withNotes(value string, notes []string) string {
+	if len(notes) == 0 {
+		return value
+	}
+	return fmt.Sprintf("%s (%s)", value, strings.Join(notes, "; "))
+}
+
+// now is the reference time for release ages.
+var now = time.Now
+
+func releasedAgo(t time.Time) string {
+	switch days := int(now().Sub(t).Hours() / 24); {
+	case days <= 0:
+		return "released today"
+	case days == 1:
+		return "released 1 day ago"
+	default:
+		return fmt.Sprintf("released %d days ago", days)
+	}
 }
 
 func (u *Update) SortKey() string {
-	return fmt.Sprint(u.Path, u.Name)
+	return fmt.Sprint(u.Root, u.Path, u.QualifiedName())
 }
 
 func (u Updates) Format(w io.Writer, as Format) error {
@@ -82,21 +197,12 @@ func (u Updates) GenerateSed() {
 
 func (u Updates) WriteMarkdownWide(w io.Writer) error {
 	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Update?", "Name", "Path", "Source", "Constraint", "Version", "Latest matching", "Latest"})
+	table.SetHeader([]string{"Update?", "Kind", "Name", "Path", "Source", "Constraint", "Version", "Latest matching", "Latest"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	rows := make([][]string, 0, len(u))
 	for _, item := range u {
-		update := ""
-		switch {
-		case item.MatchingUpdate:
-			update = "Y"
-		case item.NonMatchingUpdate:
-			update = "(Y)"
-		case item.Version == "":
-			update = "?"
-		}
-		row := []string{update, item.Name, item.Path, item.Source, item.VersionConstraint, item.Version, item.LatestMatching, item.LatestOverall}
+		row := []string{item.marker(), item.Kind, item.QualifiedName(), item.Path, item.Source, item.VersionConstraint, item.versionCell(), item.LatestMatching, item.latestCell()}
 		rows = append(rows, row)
 	}
 	table.AppendBulk(rows)
@@ -104,24 +210,22 @@ func (u Updates) WriteMarkdownWide(w io.Writer) error {
 	return nil
 }
 
+// WriteMarkdown writes a table of the updates, with a Root column if they were found under more than one scanned path,
+// and a Kind column if they include more than module calls.
 func (u Updates) WriteMarkdown(w io.Writer) error {
+	roots, kinds := make([]string, len(u)), make([]string, len(u))
+	for i, item := range u {
+		roots[i], kinds[i] = item.Root, item.Kind
+	}
+	showRoot, showKind := multipleRoots(roots), otherKinds(kinds)
 	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Update?", "Name", "Constraint", "Version", "Latest matching", "Latest"})
+	table.SetHeader(withColumn(showRoot, "Root", withColumn(showKind, "Kind", []string{"Update?", "Name", "Constraint", "Version", "Latest matching", "Latest"})))
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	rows := make([][]string, 0, len(u))
 	for _, item := range u {
-		update := ""
-		switch {
-		case item.MatchingUpdate:
-			update = "Y"
-		case item.NonMatchingUpdate:
-			update = "(Y)"
-		case item.Version == "":
-			update = "?"
-		}
-		row := []string{update, item.Name, item.VersionConstraint, item.Version, item.LatestMatching, item.LatestOverall}
-		rows = append(rows, row)
+		row := []string{item.marker(), item.QualifiedName(), item.VersionConstraint, item.versionCell(), item.LatestMatching, item.latestCell()}
+		rows = append(rows, withColumn(showRoot, item.Root, withColumn(showKind, item.Kind, row)))
 	}
 	table.AppendBulk(rows)
 	table.Render()
@@ -134,17 +238,43 @@ func (u Updates) WriteJUnit(w io.Writer) error {
 	failures := 0
 	for i, update := range u {
 		testCase := junit.JUnitTestCase{
-			Name:      update.Name,
+			Name:      update.QualifiedName(),
 			Classname: update.Path,
 			Time:      "0",
 		}
-		success := !update.MatchingUpdate
-		if !success {
+		switch {
+		case update.Status == StatusUnpinnedBranch:
+			failures++
+			message := fmt.Sprintf("Module is pinned to the branch %v rather than a version", update.Version)
+			if update.RecommendedPin != "" {
+				message += fmt.Sprintf(" (recommended pin: %v)", update.RecommendedPin)
+			}
+			testCase.Failure = &junit.JUnitFailure{
+				Message:  message,
+				Contents: "",
+			}
+		case update.MatchingUpdate:
 			failures++
 			testCase.Failure = &junit.JUnitFailure{
 				Message:  fmt.Sprintf("Module version can be updated to %v (from %v)", update.LatestMatching, update.Version),
 				Contents: "",
 			}
+		case update.InstalledUpdate:
+			failures++
+			testCase.Failure = &junit.JUnitFailure{
+				Message:  fmt.Sprintf("Installed version %v differs from the version the constraint resolves to (%v)", update.Installed, update.LatestMatching),
+				Contents: "",
+			}
+		case update.Deprecation != nil:
+			failures++
+			message := fmt.Sprintf("Module version %v is deprecated", update.Version)
+			if update.Deprecation.Reason != "" {
+				message += fmt.Sprintf(": %v", update.Deprecation.Reason)
+			}
+			testCase.Failure = &junit.JUnitFailure{
+				Message:  message,
+				Contents: update.Deprecation.Link,
+			}
 		}
 		testCases[i] = testCase
 	}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateCells(t *testing.T) {
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC) }
+	date := func(day int) *time.Time {
+		t := time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name                    string
+		update                  Update
+		wantMarker              string
+		wantVersion, wantLatest string
+	}{
+		{
+			name:        "no metadata",
+			update:      Update{Version: "1.0.0", LatestOverall: "1.0.0"},
+			wantVersion: "1.0.0",
+			wantLatest:  "1.0.0",
+		},
+		{
+			name:        "release dates",
+			update:      Update{Version: "1.0.0", PublishedAt: date(1), LatestOverall: "2.0.0", LatestPublishedAt: date(10), NonMatchingUpdate: true},
+			wantMarker:  "(Y)",
+			wantVersion: "1.0.0 (released 9 days ago)",
+			wantLatest:  "2.0.0 (released today)",
+		},
+		{
+			name:        "released yesterday",
+			update:      Update{Version: "1.0.0", PublishedAt: date(9), LatestOverall: "1.0.0", LatestPublishedAt: date(9)},
+			wantVersion: "1.0.0 (released 1 day ago)",
+			wantLatest:  "1.0.0 (released 1 day ago)",
+		},
+		{
+			name:        "deprecated",
+			update:      Update{Version: "1.0.0", PublishedAt: date(1), LatestOverall: "1.0.0", Deprecation: &Deprecation{Reason: "use acme/network/aws"}},
+			wantMarker:  "D",
+			wantVersion: "1.0.0 (released 9 days ago; deprecated)",
+			wantLatest:  "1.0.0",
+		},
+		{
+			name:        "deprecated with a matching update",
+			update:      Update{Version: "1.0.0", LatestMatching: "1.1.0", LatestOverall: "1.1.0", MatchingUpdate: true, Deprecation: &Deprecation{}},
+			wantMarker:  "Y",
+			wantVersion: "1.0.0 (deprecated)",
+			wantLatest:  "1.1.0",
+		},
+		{
+			name:        "pending cooldown",
+			update:      Update{Version: "1.0.0", LatestOverall: "1.1.0", LatestPublishedAt: date(1), PendingCooldown: []string{"1.2.0", "2.0.0"}},
+			wantVersion: "1.0.0",
+			wantLatest:  "1.1.0 (released 9 days ago; pending cooldown: 1.2.0, 2.0.0)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.update.marker(); got != tt.wantMarker {
+				t.Errorf("marker: got %q, want %q", got, tt.wantMarker)
+			}
+			if got := tt.update.versionCell(); got != tt.wantVersion {
+				t.Errorf("versionCell: got %q, want %q", got, tt.wantVersion)
+			}
+			if got := tt.update.latestCell(); got != tt.wantLatest {
+				t.Errorf("latestCell: got %q, want %q", got, tt.wantLatest)
+			}
+		})
+	}
+}
@@ -1,42 +1,147 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/credentials"
 )
 
 type Client struct {
 	HTTP *http.Client
+	// Credentials are sent as bearer tokens, each only with requests to the services discovered at its host.
+	Credentials credentials.Tokens
+	// Retry configures the retries of transient failures (the zero value doesn't retry).
+	Retry RetryPolicy
+}
+
+// RetryPolicy retries requests failing with 429, 5xx statuses or network errors, with exponential backoff.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// MinDelay is the delay before the first retry; it doubles with each further retry.
+	MinDelay time.Duration
+	// MaxDelay caps the delay between retries (0: no limit). Responses asking (via `Retry-After`)
+	// to wait longer than MaxDelay are not retried.
+	MaxDelay time.Duration
 }
 
-var errNoModuleRegistryHost = errors.New("no module registry host specified")
+// DefaultRetry is the recommended RetryPolicy.
+var DefaultRetry = RetryPolicy{MaxRetries: 3, MinDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+var (
+	errNoModuleRegistryHost   = errors.New("no module registry host specified")
+	errNoProviderRegistryHost = errors.New("no provider registry host specified")
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// StatusError is an unexpected response status from a registry.
+// It matches ErrNotFound (404), ErrUnauthorized (401, 403), and ErrRateLimited (429) with errors.Is.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	// RetryAfter is the delay requested by the response's `Retry-After` header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %q: %s", e.URL, e.Status)
+}
 
-// Discover obtains the module index base URL for the given hostname.
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func (e *StatusError) transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500 && e.StatusCode != http.StatusNotImplemented
+}
+
+// Services are the base URLs of the services offered by a registry host.
+type Services struct {
+	ModulesV1   string `json:"modules.v1"`
+	ProvidersV1 string `json:"providers.v1"`
+}
+
+// Service is a registry service discovered at Hostname. Like Terraform, requests to the service use
+// the credentials of Hostname, even if BaseURL points to another host.
+type Service struct {
+	Hostname string
+	BaseURL  string
+}
+
+// Discover obtains the service base URLs for the given hostname.
 // ref.: https://www.terraform.io/docs/registry/api.html#service-discovery
-func (c *Client) Discover(hostname string) (string, error) {
-	var response struct {
-		ModulesV1 *string `json:"modules.v1"`
+func (c *Client) Discover(ctx context.Context, hostname string) (*Services, error) {
+	var response Services
+	if err := c.getJSON(ctx, hostname, fmt.Sprintf("https://%s/.well-known/terraform.json", hostname), &response); err != nil {
+		return nil, fmt.Errorf("discover registry: %w", err)
 	}
-	resp, err := c.HTTP.Get(fmt.Sprintf("https://%s/.well-known/terraform.json", hostname))
+	return &response, nil
+}
+
+// DiscoverModules obtains the module service of the given hostname.
+func (c *Client) DiscoverModules(ctx context.Context, hostname string) (*Service, error) {
+	services, err := c.Discover(ctx, hostname)
 	if err != nil {
-		return "", fmt.Errorf("discover registry: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("decode registry response: %w", err)
+	if services.ModulesV1 == "" {
+		return nil, fmt.Errorf("%w at %q", errNoModuleRegistryHost, hostname)
+	}
+	return newService(hostname, services.ModulesV1)
+}
+
+// DiscoverProviders obtains the provider service of the given hostname.
+func (c *Client) DiscoverProviders(ctx context.Context, hostname string) (*Service, error) {
+	services, err := c.Discover(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	if services.ProvidersV1 == "" {
+		return nil, fmt.Errorf("%w at %q", errNoProviderRegistryHost, hostname)
+	}
+	return newService(hostname, services.ProvidersV1)
+}
+
+// newService resolves a (possibly relative) service URL from discovery against the registry host.
+func newService(hostname, baseURL string) (*Service, error) {
+	u, err := neturl.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse service url %q: %w", baseURL, err)
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
 	}
-	if response.ModulesV1 == nil {
-		return "", fmt.Errorf("%w at %q", errNoModuleRegistryHost, hostname)
+	if u.Host == "" {
+		u.Host = hostname
 	}
-	return *response.ModulesV1, nil
+	return &Service{Hostname: hostname, BaseURL: u.String()}, nil
 }
 
 // ListVersions lists the available module versions for the a specific module.
 // ref.: https://www.terraform.io/docs/registry/api.html#list-available-versions-for-a-specific-module
-func (c *Client) ListVersions(baseURL, namespace, name, system string) ([]string, error) {
-	url := fmt.Sprintf("%s%s/%s/%s/versions", baseURL, namespace, name, system)
+func (c *Client) ListVersions(ctx context.Context, service Service, namespace, name, system string) ([]string, error) {
+	url := fmt.Sprintf("%s%s/%s/%s/versions", service.BaseURL, namespace, name, system)
 	var response struct {
 		Modules []struct {
 			Versions []struct {
@@ -44,13 +149,8 @@ func (c *Client) ListVersions(baseURL, namespace, name, system string) ([]string
 			} `json:"versions"`
 		} `json:"modules"`
 	}
-	resp, err := c.HTTP.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("GET %q: %w", url, err)
-	}
-	defer resp.Body.Close()
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("decode registry response: %w", err)
+	if err := c.getJSON(ctx, service.Hostname, url, &response); err != nil {
+		return nil, err
 	}
 	var versions []string
 	for _, m := range response.Modules {
@@ -60,3 +160,149 @@ func (c *Client) ListVersions(baseURL, namespace, name, system string) ([]string
 	}
 	return versions, nil
 }
+
+// Module is a module version's metadata.
+type Module struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner"`
+	Namespace   string    `json:"namespace"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Provider    string    `json:"provider"`
+	Description string    `json:"description"`
+	Source      string    `json:"source"`
+	PublishedAt time.Time `json:"published_at"`
+	Downloads   int       `json:"downloads"`
+	Verified    bool      `json:"verified"`
+	// Deprecation is set for deprecated module versions (by registries that support deprecation, e.g. HCP Terraform).
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
+}
+
+type Deprecation struct {
+	Reason string `json:"reason,omitempty"`
+	Link   string `json:"link,omitempty"`
+}
+
+// GetModule fetches the metadata of a specific module version, or of the latest version if version is empty.
+// ref.: https://developer.hashicorp.com/terraform/registry/api-docs#get-a-specific-module
+func (c *Client) GetModule(ctx context.Context, service Service, namespace, name, system, version string) (*Module, error) {
+	url := fmt.Sprintf("%s%s/%s/%s", service.BaseURL, namespace, name, system)
+	if version != "" {
+		url += "/" + version
+	}
+	var response Module
+	if err := c.getJSON(ctx, service.Hostname, url, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListProviderVersions lists the available versions for a specific provider.
+// ref.: https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#list-available-versions
+func (c *Client) ListProviderVersions(ctx context.Context, service Service, namespace, providerType string) ([]string, error) {
+	url := fmt.Sprintf("%s%s/%s/versions", service.BaseURL, namespace, providerType)
+	var response struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := c.getJSON(ctx, service.Hostname, url, &response); err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(response.Versions))
+	for _, v := range response.Versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// getJSON decodes the response to a GET request, authenticated with the credentials of hostname.
+func (c *Client) getJSON(ctx context.Context, hostname, url string, response interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := c.tryGetJSON(ctx, hostname, url, response)
+		if err == nil || attempt >= c.Retry.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		delay, ok := c.Retry.delay(attempt, err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the delay before retrying the given failed attempt (counting from 0), and whether to retry at all.
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	var urlErr *neturl.Error
+	switch {
+	case errors.As(err, &statusErr):
+		if !statusErr.transient() {
+			return 0, false
+		}
+		if statusErr.RetryAfter > 0 {
+			if p.MaxDelay > 0 && statusErr.RetryAfter > p.MaxDelay {
+				return 0, false
+			}
+			return statusErr.RetryAfter, true
+		}
+	case errors.As(err, &urlErr): // network errors
+	default:
+		return 0, false
+	}
+	delay := p.MinDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, true
+}
+
+func (c *Client) tryGetJSON(ctx context.Context, hostname, url string, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("GET %q: %w", url, err)
+	}
+	if token, ok := c.Credentials.Token(hostname); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %q: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("decode registry response: %w", err)
+	}
+	return nil
+}
+
+// retryAfter parses a `Retry-After` header value, given either in seconds or as an HTTP date.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/credentials"
+)
+
+func TestCredentialsPerHost(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"modules.v1": "/v1/modules/"}`)
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	tests := []struct {
+		tokens credentials.Tokens
+		want   string
+	}{
+		{credentials.Tokens{host: "secret"}, "Bearer secret"},
+		{credentials.Tokens{"other.example.com": "secret"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		authorization = ""
+		client := Client{HTTP: server.Client(), Credentials: tt.tokens}
+		if _, err := client.Discover(context.Background(), host); err != nil {
+			t.Fatalf("Discover: %v", err)
+		}
+		if authorization != tt.want {
+			t.Errorf("Discover with %v: got Authorization %q, want %q", tt.tokens, authorization, tt.want)
+		}
+	}
+}
+
+func TestCredentialsForDiscoveredHost(t *testing.T) {
+	var authorization string
+	api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"modules": [{"versions": [{"version": "1.0.0"}]}]}`)
+	}))
+	defer api.Close()
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"modules.v1": %q}`, api.URL+"/v1/modules/")
+	}))
+	defer registry.Close()
+	host := registry.Listener.Addr().String()
+
+	client := Client{HTTP: registry.Client(), Credentials: credentials.Tokens{host: "secret"}}
+	service, err := client.DiscoverModules(context.Background(), host)
+	if err != nil {
+		t.Fatalf("DiscoverModules: %v", err)
+	}
+	if diff := cmp.Diff(&Service{Hostname: host, BaseURL: api.URL + "/v1/modules/"}, service); diff != "" {
+		t.Errorf("DiscoverModules: (-want +got)\n%s", diff)
+	}
+	if _, err := client.ListVersions(context.Background(), *service, "acme", "vpc", "aws"); err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("ListVersions: got Authorization %q, want the discovered host's token", authorization)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	retry := RetryPolicy{MaxRetries: 3, MinDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	tests := []struct {
+		name         string
+		responses    []int
+		retryAfter   string
+		wantErr      error
+		wantAttempts int
+	}{
+		{"ok", []int{200}, "", nil, 1},
+		{"transient failures", []int{503, 502, 200}, "", nil, 3},
+		{"rate limited", []int{429, 200}, "", nil, 2},
+		{"retries exhausted", []int{429, 429, 429, 429, 200}, "", ErrRateLimited, 4},
+		{"retry-after beyond max delay", []int{429, 200}, "120", ErrRateLimited, 1},
+		{"not found", []int{404, 200}, "", ErrNotFound, 1},
+		{"unauthorized", []int{401, 200}, "", ErrUnauthorized, 1},
+		{"forbidden", []int{403, 200}, "", ErrUnauthorized, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.responses[attempts.Add(1)-1]
+				if status != http.StatusOK {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(status)
+					fmt.Fprint(w, `<html>not json</html>`)
+					return
+				}
+				fmt.Fprint(w, `{"modules": [{"versions": [{"version": "1.0.0"}]}]}`)
+			}))
+			defer server.Close()
+
+			client := Client{HTTP: server.Client(), Retry: retry}
+			versions, err := client.ListVersions(context.Background(), Service{BaseURL: server.URL + "/"}, "acme", "vpc", "aws")
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("ListVersions: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (len(versions) != 1 || versions[0] != "1.0.0") {
+				t.Errorf("ListVersions: got %v, want [1.0.0]", versions)
+			}
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("ListVersions: got %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value, now); got != tt.want {
+			t.Errorf("retryAfter(%q): got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MinDelay: time.Second, MaxDelay: 5 * time.Second}
+	transient := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got, ok := policy.delay(attempt, transient); !ok || got != want {
+			t.Errorf("delay(%d): got (%v, %v), want (%v, true)", attempt, got, ok, want)
+		}
+	}
+	if got, ok := policy.delay(0, &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}); !ok || got != 3*time.Second {
+		t.Errorf("delay: got (%v, %v), want Retry-After (3s, true)", got, ok)
+	}
+	if _, ok := policy.delay(0, errors.New("decode registry response: invalid character")); ok {
+		t.Errorf("delay: decode errors should not be retried")
+	}
+}
+
+func TestGetModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/modules/acme/vpc/aws/1.0.0":
+			fmt.Fprint(w, `{"id": "acme/vpc/aws/1.0.0", "namespace": "acme", "name": "vpc", "provider": "aws", "version": "1.0.0",
+				"source": "https://github.com/acme/terraform-aws-vpc", "published_at": "2024-01-02T03:04:05.123456Z",
+				"downloads": 42, "verified": true, "deprecation": {"reason": "use acme/network/aws", "link": "https://acme.example.com"}}`)
+		case "/v1/modules/acme/vpc/aws":
+			fmt.Fprint(w, `{"id": "acme/vpc/aws/2.0.0", "version": "2.0.0", "published_at": "2024-06-01T00:00:00Z"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := Client{HTTP: server.Client()}
+	service := Service{BaseURL: server.URL + "/v1/modules/"}
+
+	got, err := client.GetModule(context.Background(), service, "acme", "vpc", "aws", "1.0.0")
+	if err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	want := &Module{
+		ID:          "acme/vpc/aws/1.0.0",
+		Namespace:   "acme",
+		Name:        "vpc",
+		Version:     "1.0.0",
+		Provider:    "aws",
+		Source:      "https://github.com/acme/terraform-aws-vpc",
+		PublishedAt: time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		Downloads:   42,
+		Verified:    true,
+		Deprecation: &Deprecation{Reason: "use acme/network/aws", Link: "https://acme.example.com"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetModule: (-want +got)\n%s", diff)
+	}
+
+	latest, err := client.GetModule(context.Background(), service, "acme", "vpc", "aws", "")
+	if err != nil {
+		t.Fatalf("GetModule: %v", err)
+	}
+	if latest.Version != "2.0.0" || latest.Deprecation != nil {
+		t.Errorf("GetModule: got version %q (deprecation %v), want 2.0.0 (none)", latest.Version, latest.Deprecation)
+	}
+
+	if _, err := client.GetModule(context.Background(), service, "acme", "missing", "aws", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetModule: got error %v, want ErrNotFound", err)
+	}
+}
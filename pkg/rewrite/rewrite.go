@@ -0,0 +1,80 @@
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/source"
+)
+
+// Rule replaces InsteadOf with URL, like git's `url.<URL>.insteadOf = <InsteadOf>`.
+type Rule struct {
+	URL       string
+	InsteadOf string
+}
+
+// ErrEmpty is returned for rules missing their URL or InsteadOf part; an empty InsteadOf would match everything.
+var ErrEmpty = errors.New("rewrite rule needs both a URL and the prefix or hostname it replaces")
+
+// NewRule returns a rule replacing insteadOf with url.
+func NewRule(url, insteadOf string) (*Rule, error) {
+	if url == "" || insteadOf == "" {
+		return nil, fmt.Errorf("%w: %q instead of %q", ErrEmpty, url, insteadOf)
+	}
+	return &Rule{URL: url, InsteadOf: insteadOf}, nil
+}
+
+// Rules redirect version lookups, e.g. to internal mirrors.
+type Rules struct {
+	// Git rules rewrite the prefixes of git remote URLs; the longest matching prefix wins.
+	Git []Rule
+	// Registry rules rewrite registry hostnames (for modules and providers); hostnames must match exactly.
+	Registry []Rule
+}
+
+// GitRemote rewrites the given git remote URL.
+func (r Rules) GitRemote(remote string) string {
+	var match *Rule
+	for i, rule := range r.Git {
+		if !strings.HasPrefix(remote, rule.InsteadOf) {
+			continue
+		}
+		if match == nil || len(rule.InsteadOf) > len(match.InsteadOf) {
+			match = &r.Git[i]
+		}
+	}
+	if match == nil {
+		return remote
+	}
+	return match.URL + strings.TrimPrefix(remote, match.InsteadOf)
+}
+
+// RegistryHostname rewrites the given registry hostname.
+func (r Rules) RegistryHostname(hostname string) string {
+	for _, rule := range r.Registry {
+		if strings.EqualFold(rule.InsteadOf, hostname) {
+			return rule.URL
+		}
+	}
+	return hostname
+}
+
+// Apply returns a copy of s with its git remote or registry hostname rewritten.
+func (r Rules) Apply(s source.Source) source.Source {
+	switch {
+	case s.Git != nil:
+		git := *s.Git
+		git.Remote = r.GitRemote(git.Remote)
+		s.Git = &git
+	case s.Registry != nil:
+		reg := *s.Registry
+		reg.Hostname = r.RegistryHostname(reg.Hostname)
+		s.Registry = &reg
+	case s.Provider != nil:
+		provider := *s.Provider
+		provider.Hostname = r.RegistryHostname(provider.Hostname)
+		s.Provider = &provider
+	}
+	return s
+}
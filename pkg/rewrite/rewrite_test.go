@@ -0,0 +1,88 @@
+package rewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/source"
+)
+
+func TestRulesApply(t *testing.T) {
+	rules := Rules{
+		Git: []Rule{
+			{URL: "https://git-mirror.internal/github/", InsteadOf: "https://github.com/"},
+			{URL: "https://git-mirror.internal/acme/", InsteadOf: "https://github.com/acme/"},
+		},
+		Registry: []Rule{
+			{URL: "registry-proxy.internal", InsteadOf: "registry.terraform.io"},
+		},
+	}
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"github.com/hashicorp/terraform-aws-consul", "https://git-mirror.internal/github/hashicorp/terraform-aws-consul.git"},
+		{"git::https://github.com/acme/infra.git?ref=v1.0.0", "https://git-mirror.internal/acme/infra.git"},
+		{"git::ssh://git@github.com/acme/infra.git", "ssh://git@github.com/acme/infra.git"},
+		{"hashicorp/consul/aws", "registry-proxy.internal"},
+		{"example.com/hashicorp/consul/aws", "example.com"},
+	}
+	for _, tt := range tests {
+		s, err := source.Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.raw, err)
+		}
+		original := s.URI()
+		rewritten := rules.Apply(*s)
+		var got string
+		switch {
+		case rewritten.Git != nil:
+			got = rewritten.Git.Remote
+		case rewritten.Registry != nil:
+			got = rewritten.Registry.Hostname
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Apply(%q): (-want +got)\n%s", tt.raw, diff)
+		}
+		if s.URI() != original {
+			t.Errorf("Apply(%q): modified the original source", tt.raw)
+		}
+	}
+}
+
+func TestRulesApplyProvider(t *testing.T) {
+	rules := Rules{Registry: []Rule{{URL: "registry-proxy.internal", InsteadOf: "registry.terraform.io"}}}
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"hashicorp/aws", "registry-proxy.internal"},
+		{"Registry.Terraform.io/hashicorp/aws", "registry-proxy.internal"},
+		{"registry.example.com/acme/internal", "registry.example.com"},
+	}
+	for _, tt := range tests {
+		s, err := source.ParseProvider(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseProvider(%q): %v", tt.raw, err)
+		}
+		rewritten := rules.Apply(*s)
+		if diff := cmp.Diff(tt.want, rewritten.Provider.Hostname); diff != "" {
+			t.Errorf("Apply(%q): (-want +got)\n%s", tt.raw, diff)
+		}
+		if rewritten.Provider == s.Provider {
+			t.Errorf("Apply(%q): shares the original provider", tt.raw)
+		}
+	}
+}
+
+func TestNewRule(t *testing.T) {
+	if _, err := NewRule("https://git-mirror.internal/", "https://github.com/"); err != nil {
+		t.Errorf("NewRule: %v", err)
+	}
+	for _, args := range [][2]string{{"https://git-mirror.internal/", ""}, {"", "https://github.com/"}} {
+		if _, err := NewRule(args[0], args[1]); !errors.Is(err, ErrEmpty) {
+			t.Errorf("NewRule(%q, %q): got error %v, want %v", args[0], args[1], err, ErrEmpty)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+)
+
+const lockFileName = ".terraform.lock.hcl"
+
+type lockFile struct {
+	Providers []struct {
+		Address     string   `hcl:"address,label"`
+		Version     string   `hcl:"version"`
+		Constraints string   `hcl:"constraints,optional"`
+		Remain      hcl.Body `hcl:",remain"`
+	} `hcl:"provider,block"`
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// lockFile reads the provider versions recorded in the dependency lock file in dir, if there is one.
+// ref.: https://developer.hashicorp.com/terraform/language/files/dependency-lock
+func (s *scanner) lockFile(dir string) ([]Result, error) {
+	path := filepath.Join(dir, lockFileName)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("read dependency lock file %q: %w", path, diags)
+	}
+	var lock lockFile
+	if diags := gohcl.DecodeBody(file.Body, nil, &lock); diags.HasErrors() {
+		return nil, fmt.Errorf("decode dependency lock file %q: %w", path, diags)
+	}
+	out := make([]Result, 0, len(lock.Providers))
+	for _, p := range lock.Providers {
+		name := p.Address
+		if provider, err := tfaddr.ParseProviderSource(p.Address); err == nil {
+			name = provider.ForDisplay()
+		}
+		out = append(out, Result{
+			Kind: KindLock,
+			Path: path,
+			Root: s.root,
+			ModuleCall: tfconfig.ModuleCall{
+				Name:    name,
+				Source:  p.Address,
+				Version: p.Constraints,
+			},
+			InstalledVersion: p.Version,
+		})
+	}
+	return out, nil
+}
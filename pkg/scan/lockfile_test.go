@@ -0,0 +1,43 @@
+package scan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+func TestLockFile(t *testing.T) {
+	dir := filepath.Join("testdata", "lock")
+	path := filepath.Join(dir, lockFileName)
+	s := scanner{root: dir}
+	got, err := s.lockFile(dir)
+	if err != nil {
+		t.Fatalf("lockFile: %v", err)
+	}
+	want := []Result{
+		{
+			Kind:             KindLock,
+			Path:             path,
+			Root:             dir,
+			ModuleCall:       tfconfig.ModuleCall{Name: "hashicorp/aws", Source: "registry.terraform.io/hashicorp/aws", Version: "~> 5.0"},
+			InstalledVersion: "5.31.0",
+		},
+		{
+			Kind:             KindLock,
+			Path:             path,
+			Root:             dir,
+			ModuleCall:       tfconfig.ModuleCall{Name: "hashicorp/random", Source: "registry.terraform.io/hashicorp/random"},
+			InstalledVersion: "3.6.0",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("lockFile: (-want +got)\n%s", diff)
+	}
+
+	got, err = s.lockFile(filepath.Join("testdata", "follow"))
+	if err != nil || got != nil {
+		t.Errorf("lockFile (no lock file): got (%v, %v), want (nil, nil)", got, err)
+	}
+}
@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+// modulesManifestPath is where `terraform init` records the installed modules, relative to the root module.
+var modulesManifestPath = filepath.Join(".terraform", "modules", "modules.json")
+
+// readModulesManifest returns the installed module versions by module key, or nil if dir has not been initialized.
+// ref.: https://github.com/hashicorp/terraform/blob/v1.9.0/internal/modsdir/manifest.go
+func readModulesManifest(dir string) (map[string]string, error) {
+	path := filepath.Join(dir, modulesManifestPath)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read modules manifest: %w", err)
+	}
+	defer f.Close()
+	var manifest struct {
+		Modules []struct {
+			Key     string `json:"Key"`
+			Version string `json:"Version"`
+		} `json:"Modules"`
+	}
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decode modules manifest %q: %w", path, err)
+	}
+	out := make(map[string]string, len(manifest.Modules))
+	for _, m := range manifest.Modules {
+		if m.Version == "" {
+			continue
+		}
+		out[m.Key] = m.Version
+	}
+	return out, nil
+}
+
+// moduleKey is the key under which a module call is recorded in the modules manifest.
+func moduleKey(parents []tfconfig.ModuleCall, name string) string {
+	parts := make([]string, 0, len(parents)+1)
+	for _, p := range parents {
+		parts = append(parts, p.Name)
+	}
+	return strings.Join(append(parts, name), ".")
+}
@@ -0,0 +1,39 @@
+package scan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadModulesManifest(t *testing.T) {
+	got, err := readModulesManifest(filepath.Join("testdata", "installed"))
+	if err != nil {
+		t.Fatalf("readModulesManifest: %v", err)
+	}
+	want := map[string]string{"app.rds": "6.1.0", "vpc": "5.2.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("readModulesManifest: (-want +got)\n%s", diff)
+	}
+
+	got, err = readModulesManifest(filepath.Join("testdata", "follow"))
+	if err != nil || got != nil {
+		t.Errorf("readModulesManifest (not initialized): got (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func TestScanInstalled(t *testing.T) {
+	results, err := Scan([]string{filepath.Join("testdata", "installed")}, Options{FollowLocal: true, Installed: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	got := make(map[string]string)
+	for _, r := range results {
+		got[moduleKey(r.Parents, r.ModuleCall.Name)] = r.InstalledVersion
+	}
+	want := map[string]string{"app": "", "app.rds": "6.1.0", "vpc": "5.2.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Scan: installed versions (-want +got)\n%s", diff)
+	}
+}
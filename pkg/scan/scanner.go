@@ -1,32 +1,299 @@
 package scan
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/gobwas/glob"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 )
 
+type Kind string
+
+const (
+	// KindModule is a `module` block in Terraform configuration.
+	KindModule Kind = "module"
+	// KindTerragrunt is the `terraform { source = ... }` attribute of a terragrunt.hcl file.
+	KindTerragrunt Kind = "terragrunt"
+	// KindProvider is an entry of a `required_providers` block.
+	// Its ModuleCall holds the provider's local name, source address and version constraints.
+	KindProvider Kind = "provider"
+	// KindCore is a module's `terraform { required_version = ... }` setting.
+	// Its ModuleCall holds the (joined) version constraints.
+	KindCore Kind = "core"
+	// KindLock is a provider entry of a dependency lock file (.terraform.lock.hcl).
+	// Its ModuleCall holds the provider's address and the recorded constraints,
+	// its InstalledVersion the locked version.
+	KindLock Kind = "lock"
+)
+
 type Result struct {
+	Kind       Kind
 	ModuleCall tfconfig.ModuleCall
 	Path       string
+	// Root is the path (as given to Scan) under which the module call was found.
+	Root string
+	// Parents is the chain of local module calls leading to ModuleCall, outermost first.
+	Parents []tfconfig.ModuleCall
+	// InstalledVersion is the version recorded for the module call in the root module's
+	// .terraform/modules/modules.json manifest (only set with Options.Installed),
+	// or the locked version of a KindLock provider.
+	InstalledVersion string
 }
 
-func Scan(paths []string) ([]Result, error) {
-	var out []Result
-	for _, path := range paths {
-		module, err := tfconfig.LoadModule(path)
-		if err != nil {
-			return nil, fmt.Errorf("read terraform module %q: %w", path, err)
+type Options struct {
+	// Recursive makes Scan walk each path and load every directory containing Terraform files.
+	Recursive bool
+	// Include, if non-empty, restricts a recursive scan to directories matching at least one of the patterns.
+	Include []glob.Glob
+	// Exclude skips directories (and everything below them) matching any of the patterns during a recursive scan.
+	Exclude []glob.Glob
+	// FollowLocal makes Scan load the modules referenced by local module calls and report their module calls, too.
+	FollowLocal bool
+	// Providers makes Scan report the modules' provider requirements, too.
+	Providers bool
+	// Core makes Scan report the modules' Terraform core version requirements, too.
+	Core bool
+	// Installed makes Scan read the module versions installed by `terraform init` from each root module's manifest.
+	Installed bool
+	// Lock makes Scan report the provider versions locked in each root module's dependency lock file.
+	Lock bool
+}
+
+var ErrModuleCycle = errors.New("module call cycle")
+
+// Scan reports the module calls of the modules in the given paths.
+// With FollowLocal, modules called by other scanned modules are reported through those calls (with their Parents),
+// rather than scanned on their own, regardless of the order they are found in. Each module directory is scanned
+// only once per chain of parents. Module call cycles are not followed; they are returned (joined, wrapping
+// ErrModuleCycle) along with the results. Any other error aborts the scan.
+func Scan(paths []string, opts Options) ([]Result, error) {
+	var dirs []rootDir
+	for _, root := range paths {
+		rootDirs := []string{root}
+		if opts.Recursive {
+			var err error
+			rootDirs, err = moduleDirs(root, opts.Include, opts.Exclude)
+			if err != nil {
+				return nil, err
+			}
 		}
-		for _, call := range module.ModuleCalls {
-			if call == nil {
+		for _, dir := range rootDirs {
+			absDir, err := filepath.Abs(dir)
+			if err != nil {
+				return nil, fmt.Errorf("resolve module path %q: %w", dir, err)
+			}
+			dirs = append(dirs, rootDir{root: root, dir: dir, absDir: absDir})
+		}
+	}
+	state := &state{visited: make(map[string]bool), modules: make(map[string]*tfconfig.Module)}
+	called := make(map[string]bool)
+	if opts.FollowLocal {
+		for _, d := range dirs {
+			module, err := state.load(d.dir, d.absDir)
+			if err != nil {
+				return nil, err
+			}
+			for _, call := range module.ModuleCalls {
+				if call != nil && isLocalSource(call.Source) {
+					called[filepath.Join(d.absDir, call.Source)] = true
+				}
+			}
+		}
+	}
+	var out []Result
+	// scan the modules not called by others first; modules called only within a cycle are left over for the second pass
+	for pass := 0; pass < 2; pass++ {
+		for _, d := range dirs {
+			if state.visited[d.absDir] || pass == 0 && called[d.absDir] {
 				continue
 			}
-			out = append(out, Result{
-				Path:       call.Pos.Filename,
-				ModuleCall: *call,
-			})
+			results, err := scanDir(d, opts, state)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, results...)
+		}
+	}
+	return out, errors.Join(state.cycles...)
+}
+
+// rootDir is a module directory found under a path given to Scan.
+type rootDir struct {
+	root, dir, absDir string
+}
+
+// scanDir scans a module directory on its own.
+func scanDir(d rootDir, opts Options, state *state) ([]Result, error) {
+	s := scanner{root: d.root, opts: opts, state: state}
+	if opts.Installed {
+		installed, err := readModulesManifest(d.dir)
+		if err != nil {
+			return nil, err
 		}
+		s.installed = installed
+	}
+	out, err := s.module(d.dir, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Lock {
+		results, err := s.lockFile(d.dir)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, results...)
+	}
+	if !isTerragruntDir(d.dir) {
+		return out, nil
+	}
+	results, err := scanTerragrunt(d.root, d.dir)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, results...), nil
+}
+
+// scanner scans a root module and (optionally) the local modules it calls.
+type scanner struct {
+	root      string
+	opts      Options
+	installed map[string]string
+	state     *state
+}
+
+// state is shared by the scanners of a Scan. It tracks module directories by absolute path.
+type state struct {
+	// visited are all directories loaded so far, on their own or by following local module calls.
+	visited map[string]bool
+	// modules caches the loaded modules.
+	modules map[string]*tfconfig.Module
+	cycles  []error
+}
+
+// load loads the module in dir (with absolute path absDir), once.
+func (s *state) load(dir, absDir string) (*tfconfig.Module, error) {
+	if module, ok := s.modules[absDir]; ok {
+		return module, nil
+	}
+	module, diags := tfconfig.LoadModule(dir)
+	if diags != nil {
+		return nil, fmt.Errorf("read terraform module %q: %w", dir, diags)
+	}
+	s.modules[absDir] = module
+	return module, nil
+}
+
+// module loads the module in dir and returns its module calls.
+// stack holds the (absolute) directories of the modules in the current chain of parents.
+func (s *scanner) module(dir string, parents []tfconfig.ModuleCall, stack []string) ([]Result, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve module path %q: %w", dir, err)
+	}
+	module, err := s.state.load(dir, absDir)
+	if err != nil {
+		return nil, err
+	}
+	stack = append(stack, absDir)
+	s.state.visited[absDir] = true
+	var out []Result
+	if s.opts.Providers {
+		out = append(out, s.providers(dir, module, parents)...)
+	}
+	if s.opts.Core && len(module.RequiredCore) > 0 {
+		out = append(out, Result{
+			Kind: KindCore,
+			Path: dir,
+			Root: s.root,
+			ModuleCall: tfconfig.ModuleCall{
+				Name:    "terraform",
+				Source:  "terraform",
+				Version: strings.Join(module.RequiredCore, ", "),
+			},
+			Parents: parents,
+		})
+	}
+	for _, call := range module.ModuleCalls {
+		if call == nil {
+			continue
+		}
+		out = append(out, Result{
+			Kind:             KindModule,
+			Path:             call.Pos.Filename,
+			Root:             s.root,
+			ModuleCall:       *call,
+			Parents:          parents,
+			InstalledVersion: s.installed[moduleKey(parents, call.Name)],
+		})
+		if !s.opts.FollowLocal || !isLocalSource(call.Source) {
+			continue
+		}
+		childDir := filepath.Join(dir, call.Source)
+		absChildDir := filepath.Join(absDir, call.Source)
+		if slices.Contains(stack, absChildDir) {
+			s.state.cycles = append(s.state.cycles, fmt.Errorf("%w: module %q in %q calls %q", ErrModuleCycle, call.Name, dir, call.Source))
+			continue
+		}
+		childParents := make([]tfconfig.ModuleCall, len(parents), len(parents)+1)
+		copy(childParents, parents)
+		childParents = append(childParents, *call)
+		results, err := s.module(childDir, childParents, stack)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, results...)
 	}
 	return out, nil
 }
+
+func (s *scanner) providers(dir string, module *tfconfig.Module, parents []tfconfig.ModuleCall) []Result {
+	out := make([]Result, 0, len(module.RequiredProviders))
+	for name, req := range module.RequiredProviders {
+		if req == nil {
+			continue
+		}
+		source := req.Source
+		if source == "" {
+			source = name
+		}
+		out = append(out, Result{
+			Kind: KindProvider,
+			Path: dir,
+			Root: s.root,
+			ModuleCall: tfconfig.ModuleCall{
+				Name:    name,
+				Source:  source,
+				Version: strings.Join(req.VersionConstraints, ", "),
+			},
+			Parents: parents,
+		})
+	}
+	return out
+}
+
+// isLocalSource reports whether a module source address refers to a local directory.
+// ref.: https://developer.hashicorp.com/terraform/language/modules/sources#local-paths
+func isLocalSource(source string) bool {
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParentNames returns the names of the parent module calls, outermost first.
+func (r Result) ParentNames() []string {
+	if len(r.Parents) == 0 {
+		return nil
+	}
+	out := make([]string, len(r.Parents))
+	for i, p := range r.Parents {
+		out[i] = p.Name
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanFollowLocal(t *testing.T) {
+	want := []string{
+		"app",
+		"app.db",
+		"app.db.rds",
+		"app.loop",
+		"app.loop.back",
+		"app.loop.sg",
+		"vpc",
+	}
+	tests := []struct {
+		name  string
+		paths []string
+		opts  Options
+		want  []string
+	}{
+		{"follow local", []string{"testdata/follow"}, Options{FollowLocal: true}, want},
+		{"recursive", []string{"testdata/follow"}, Options{FollowLocal: true, Recursive: true}, want},
+		{"called module first", []string{"testdata/follow/modules/app", "testdata/follow"}, Options{FollowLocal: true}, want},
+		{
+			// every module is called by another one, so the cycle is entered at the first module found
+			"cycle without caller", []string{"testdata/follow/modules"}, Options{FollowLocal: true, Recursive: true},
+			[]string{"db", "db.rds", "loop", "loop.back", "loop.sg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Scan(tt.paths, tt.opts)
+			if !errors.Is(err, ErrModuleCycle) {
+				t.Fatalf("Scan: got error %v, want %v", err, ErrModuleCycle)
+			}
+			if !strings.Contains(err.Error(), `module "back"`) {
+				t.Errorf("Scan: cycle error %q does not name the module call", err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, strings.Join(append(r.ParentNames(), r.ModuleCall.Name), "."))
+			}
+			slices.Sort(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Scan: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package scan
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const terragruntFileName = "terragrunt.hcl"
+
+var terragruntSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+}
+
+var terragruntTerraformSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}},
+}
+
+func isTerragruntDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, terragruntFileName))
+	return err == nil && !info.IsDir()
+}
+
+// scanTerragrunt reads the `terraform { source = ... }` attribute of the terragrunt.hcl file in dir.
+// Sources that can't be evaluated statically (e.g. because they reference locals) are skipped.
+func scanTerragrunt(root, dir string) ([]Result, error) {
+	path := filepath.Join(dir, terragruntFileName)
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("read terragrunt config %q: %w", path, diags)
+	}
+	content, _, diags := file.Body.PartialContent(terragruntSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("read terragrunt config %q: %w", path, diags)
+	}
+	var out []Result
+	for _, block := range content.Blocks {
+		blockContent, _, diags := block.Body.PartialContent(terragruntTerraformSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("read terragrunt config %q: %w", path, diags)
+		}
+		attr, ok := blockContent.Attributes["source"]
+		if !ok {
+			continue
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || value.Type() != cty.String || !value.IsKnown() || value.IsNull() {
+			continue
+		}
+		call := terragruntModuleCall(value.AsString())
+		call.Name = terragruntName(root, dir)
+		call.Pos = tfconfig.SourcePos{
+			Filename: path,
+			Line:     attr.Range.Start.Line,
+		}
+		out = append(out, Result{
+			Kind:       KindTerragrunt,
+			Path:       path,
+			Root:       root,
+			ModuleCall: call,
+		})
+	}
+	return out, nil
+}
+
+// terragruntModuleCall translates a Terragrunt source into the equivalent module call.
+// Terragrunt's registry sources (tfr://[<HOST>]/<NAMESPACE>/<NAME>/<SYSTEM>?version=<VERSION>)
+// are turned into a registry source address with a version; all other sources
+// already use Terraform's (go-getter) syntax.
+// ref.: https://terragrunt.gruntwork.io/docs/reference/config-blocks-and-attributes/#a-note-about-using-modules-from-the-registry
+func terragruntModuleCall(raw string) tfconfig.ModuleCall {
+	const tfrScheme = "tfr://"
+	if !strings.HasPrefix(raw, tfrScheme) {
+		return tfconfig.ModuleCall{Source: raw}
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return tfconfig.ModuleCall{Source: raw}
+	}
+	source := strings.TrimPrefix(u.Path, "/")
+	if u.Host != "" {
+		source = u.Host + "/" + source
+	}
+	return tfconfig.ModuleCall{
+		Source:  source,
+		Version: u.Query().Get("version"),
+	}
+}
+
+// terragruntName names a Terragrunt module after its directory relative to the scanned root.
+func terragruntName(root, dir string) string {
+	if rel, err := filepath.Rel(root, dir); err == nil && rel != "." {
+		return filepath.ToSlash(rel)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return filepath.Base(abs)
+	}
+	return dir
+}
@@ -0,0 +1,83 @@
+package scan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+func TestTerragruntModuleCall(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want tfconfig.ModuleCall
+	}{
+		{
+			raw:  "tfr:///terraform-aws-modules/vpc/aws?version=3.3.0",
+			want: tfconfig.ModuleCall{Source: "terraform-aws-modules/vpc/aws", Version: "3.3.0"},
+		},
+		{
+			raw:  "tfr://registry.example.com/org/vpc/aws?version=1.0.0",
+			want: tfconfig.ModuleCall{Source: "registry.example.com/org/vpc/aws", Version: "1.0.0"},
+		},
+		{
+			raw:  "tfr:///terraform-aws-modules/iam/aws//modules/iam-role?version=5.0.0",
+			want: tfconfig.ModuleCall{Source: "terraform-aws-modules/iam/aws//modules/iam-role", Version: "5.0.0"},
+		},
+		{
+			raw:  "tfr:///terraform-aws-modules/vpc/aws",
+			want: tfconfig.ModuleCall{Source: "terraform-aws-modules/vpc/aws"},
+		},
+		{
+			raw:  "git::https://github.com/org/modules.git//dns?ref=v1.2.0",
+			want: tfconfig.ModuleCall{Source: "git::https://github.com/org/modules.git//dns?ref=v1.2.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got := terragruntModuleCall(tt.raw)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("terragruntModuleCall(%q):\n%s", tt.raw, diff)
+			}
+		})
+	}
+}
+
+func TestScanTerragrunt(t *testing.T) {
+	root := filepath.Join("testdata", "terragrunt")
+	tests := []struct {
+		dir  string
+		want []Result
+	}{
+		{
+			dir: filepath.Join(root, "live", "prod"),
+			want: []Result{{
+				Kind: KindTerragrunt,
+				Path: filepath.Join(root, "live", "prod", "terragrunt.hcl"),
+				Root: root,
+				ModuleCall: tfconfig.ModuleCall{
+					Name:    "live/prod",
+					Source:  "terraform-aws-modules/vpc/aws",
+					Version: "5.0.0",
+					Pos:     tfconfig.SourcePos{Filename: filepath.Join(root, "live", "prod", "terragrunt.hcl"), Line: 6},
+				},
+			}},
+		},
+		{
+			// sources referencing locals can't be evaluated statically
+			dir: filepath.Join(root, "live", "dev"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := scanTerragrunt(root, tt.dir)
+			if err != nil {
+				t.Fatalf("scanTerragrunt: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("scanTerragrunt(%q): (-want +got)\n%s", tt.dir, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+module "db" {
+  source = "../db"
+}
+
+module "loop" {
+  source = "../loop"
+}
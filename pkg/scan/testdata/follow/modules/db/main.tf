@@ -0,0 +1,4 @@
+module "rds" {
+  source  = "terraform-aws-modules/rds/aws"
+  version = "6.0.0"
+}
@@ -0,0 +1,8 @@
+module "back" {
+  source = "../app"
+}
+
+module "sg" {
+  source  = "terraform-aws-modules/security-group/aws"
+  version = "5.1.0"
+}
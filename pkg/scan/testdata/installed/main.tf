@@ -0,0 +1,8 @@
+module "app" {
+  source = "./modules/app"
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.0"
+}
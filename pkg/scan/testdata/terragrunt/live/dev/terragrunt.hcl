@@ -0,0 +1,7 @@
+locals {
+  version = "v1.2.0"
+}
+
+terraform {
+  source = "git::https://github.com/acme/modules.git//dns?ref=${local.version}"
+}
@@ -0,0 +1,61 @@
+package scan
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/gobwas/glob"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+var skipDirNames = map[string]bool{
+	".terraform":        true,
+	".git":              true,
+	".terragrunt-cache": true,
+}
+
+// moduleDirs lists the directories below root that contain Terraform files or a terragrunt.hcl file.
+// Patterns are matched against the slash-separated path relative to root.
+func moduleDirs(root string, include, exclude []glob.Glob) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && skipDirNames[d.Name()] {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(exclude, rel) {
+			return filepath.SkipDir
+		}
+		if len(include) > 0 && !matchAny(include, rel) {
+			return nil
+		}
+		if tfconfig.IsModuleDir(path) || isTerragruntDir(path) {
+			out = append(out, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %q: %w", root, err)
+	}
+	return out, nil
+}
+
+func matchAny(patterns []glob.Glob, s string) bool {
+	for _, p := range patterns {
+		if p.Match(s) {
+			return true
+		}
+	}
+	return false
+}
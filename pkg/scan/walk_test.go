@@ -0,0 +1,85 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gobwas/glob"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleDirs(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"main.tf",
+		"live/dev/main.tf",
+		"live/prod/terragrunt.hcl",
+		"live/prod/.terragrunt-cache/abc/main.tf",
+		"modules/vpc/main.tf",
+		"modules/vpc/.terraform/modules/sg/main.tf",
+		".git/hooks/main.tf",
+		"docs/README.md",
+	} {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	compile := func(patterns ...string) []glob.Glob {
+		var out []glob.Glob
+		for _, p := range patterns {
+			out = append(out, glob.MustCompile(p, '/'))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name             string
+		include, exclude []glob.Glob
+		want             []string
+	}{
+		{
+			name: "all",
+			want: []string{".", "live/dev", "live/prod", "modules/vpc"},
+		},
+		{
+			name:    "include",
+			include: compile("live/*"),
+			want:    []string{"live/dev", "live/prod"},
+		},
+		{
+			name:    "exclude",
+			exclude: compile("modules"),
+			want:    []string{".", "live/dev", "live/prod"},
+		},
+		{
+			name:    "include and exclude",
+			include: compile("live/*"),
+			exclude: compile("**/prod"),
+			want:    []string{"live/dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs, err := moduleDirs(root, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("moduleDirs: %v", err)
+			}
+			var got []string
+			for _, dir := range dirs {
+				rel, err := filepath.Rel(root, dir)
+				if err != nil {
+					t.Fatalf("Rel: %v", err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("moduleDirs: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
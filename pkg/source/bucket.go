@@ -0,0 +1,125 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+// Bucket is a module archive stored in an S3 (or S3-compatible) or GCS bucket, e.g.
+// `s3::https://s3.amazonaws.com/bucket/modules/vpc/vpc-1.4.0.zip`.
+// Its available versions are the objects next to the archive, i.e. under the key's prefix.
+type Bucket struct {
+	// Service is "s3" or "gcs".
+	Service string
+	// Endpoint is the URL of an S3-compatible service other than AWS S3 (e.g. MinIO).
+	Endpoint string
+	Region   string
+	Bucket   string
+	Key      string
+	Subdir   string
+	// KeyPattern extracts the versions from object names (the last element of object keys).
+	KeyPattern *tags.Pattern
+	// Package is the normalized address of the key's prefix, without the object name and Subdir.
+	Package    string
+	Normalized string
+}
+
+// Prefix is the key's directory, including the trailing slash ("" for objects at the bucket's root).
+func (b *Bucket) Prefix() string {
+	dir := path.Dir(b.Key)
+	if dir == "." {
+		return ""
+	}
+	return dir + "/"
+}
+
+// Object is the last element of the key, e.g. `vpc-1.4.0.zip`.
+func (b *Bucket) Object() string {
+	return path.Base(b.Key)
+}
+
+// HostPath is the bucket name and the key's directory, e.g. `bucket/modules/vpc`.
+func (b *Bucket) HostPath() string {
+	return path.Join(b.Bucket, path.Dir(b.Key))
+}
+
+const awsDomain = ".amazonaws.com"
+
+// parseS3 parses the URL of an S3 object in any of the forms understood by go-getter's S3 getter.
+func parseS3(raw string) (*Bucket, error) {
+	dir, subDir := getter.SourceDirSubdir(raw)
+	u, err := url.Parse(dir)
+	if err != nil {
+		return nil, fmt.Errorf("parse s3 source: %w", err)
+	}
+	out := Bucket{Service: "s3", Subdir: subDir}
+	if host, ok := strings.CutSuffix(u.Host, awsDomain); ok {
+		hostParts := strings.Split(host, ".")
+		switch len(hostParts) {
+		case 1: // path-style: s3[-region].amazonaws.com/bucket/key
+			out.Region = strings.TrimPrefix(strings.TrimPrefix(hostParts[0], "s3-"), "s3")
+			bucket, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+			out.Bucket, out.Key = bucket, key
+		case 2:
+			if hostParts[0] == "s3" { // path-style: s3.region.amazonaws.com/bucket/key
+				out.Region = hostParts[1]
+				bucket, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+				out.Bucket, out.Key = bucket, key
+				break
+			}
+			// vhost-style: bucket.s3[-region].amazonaws.com/key
+			out.Region = strings.TrimPrefix(strings.TrimPrefix(hostParts[1], "s3-"), "s3")
+			out.Bucket, out.Key = hostParts[0], strings.TrimPrefix(u.Path, "/")
+		case 3: // vhost-style: bucket.s3.region.amazonaws.com/key
+			out.Region = hostParts[2]
+			out.Bucket, out.Key = hostParts[0], strings.TrimPrefix(u.Path, "/")
+		}
+		if out.Region == "" {
+			out.Region = "us-east-1"
+		}
+	} else { // S3-compatible service: <endpoint>/bucket/key
+		out.Endpoint = (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+		out.Region = u.Query().Get("region")
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+		out.Bucket, out.Key = bucket, key
+	}
+	if out.Bucket == "" || out.Key == "" || strings.HasSuffix(out.Key, "/") {
+		return nil, fmt.Errorf("parse s3 source %q: not a valid S3 URL", raw)
+	}
+	out.setNormalized(u)
+	return &out, nil
+}
+
+// parseGCS parses the URL of a GCS object (`https://www.googleapis.com/storage/v1/<BUCKET>/<KEY>`).
+func parseGCS(raw string) (*Bucket, error) {
+	dir, subDir := getter.SourceDirSubdir(raw)
+	u, err := url.Parse(dir)
+	if err != nil {
+		return nil, fmt.Errorf("parse gcs source: %w", err)
+	}
+	pathParts := strings.SplitN(u.Path, "/", 5)
+	if !strings.HasSuffix(u.Host, ".googleapis.com") || len(pathParts) != 5 || pathParts[3] == "" || pathParts[4] == "" {
+		return nil, fmt.Errorf("parse gcs source %q: not a valid GCS URL", raw)
+	}
+	out := Bucket{Service: "gcs", Bucket: pathParts[3], Key: pathParts[4], Subdir: subDir}
+	out.setNormalized(u)
+	return &out, nil
+}
+
+func (b *Bucket) setNormalized(u *url.URL) {
+	object := *u
+	object.RawQuery, object.Fragment = "", ""
+	b.Normalized = b.Service + "::" + object.String()
+	prefix := object
+	prefix.Path = path.Dir(object.Path) + "/"
+	prefix.RawPath = ""
+	b.Package = b.Service + "::" + prefix.String()
+	if b.Subdir != "" {
+		b.Normalized += "//" + b.Subdir
+	}
+}
@@ -0,0 +1,7 @@
+package source
+
+// Core is a Terraform core version requirement (`terraform { required_version = ... }`).
+// Its versions come from a releases index rather than a module source.
+type Core struct {
+	Product string
+}
@@ -3,14 +3,38 @@ package source
 import (
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 
 	getter "github.com/hashicorp/go-getter"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
 )
 
 type Git struct {
 	Remote     string
 	RefValue   *string
 	RemotePath *string
+	// TagPattern, if set, selects the tags holding versions and extracts the versions from them.
+	TagPattern *tags.Pattern
+}
+
+var commitHash = regexp.MustCompile(`^(?:[0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// Commit returns the ref if it is a full commit hash (SHA-1 or SHA-256).
+func (g *Git) Commit() (string, bool) {
+	if g.RefValue == nil || !commitHash.MatchString(*g.RefValue) {
+		return "", false
+	}
+	return *g.RefValue, true
+}
+
+// HostPath is the remote's host and repository path, e.g. `github.com/acme/infra`.
+func (g *Git) HostPath() string {
+	u, err := url.Parse(g.Remote)
+	if err != nil {
+		return g.Remote
+	}
+	return u.Hostname() + "/" + strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
 }
 
 func parseGitURL(s string) (*Git, error) {
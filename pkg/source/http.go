@@ -0,0 +1,62 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+
+	getter "github.com/hashicorp/go-getter"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+// HTTP is a module archive downloaded over HTTP(S), e.g. `https://artifacts.internal/modules/dns-1.2.0.tar.gz`.
+// Its available versions are the archives next to it, listed in a JSON index or an HTML directory listing.
+type HTTP struct {
+	URL    string
+	Subdir string
+	// NamePattern extracts the versions from archive names (the last element of their URL paths).
+	NamePattern *tags.Pattern
+	// Index, if set, is the URL of a JSON index listing the available archives.
+	Index string
+	// Package is the URL of the archive's directory, without the archive name and Subdir.
+	Package    string
+	Normalized string
+}
+
+// Object is the last element of the URL's path, e.g. `dns-1.2.0.tar.gz`.
+func (h *HTTP) Object() string {
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// HostPath is the URL's host and directory, e.g. `artifacts.internal/modules`.
+func (h *HTTP) HostPath() string {
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return h.URL
+	}
+	return path.Join(u.Host, path.Dir(u.Path))
+}
+
+func parseHTTP(raw string) (*HTTP, error) {
+	dir, subDir := getter.SourceDirSubdir(raw)
+	u, err := url.Parse(dir)
+	if err != nil {
+		return nil, fmt.Errorf("parse http source: %w", err)
+	}
+	if u.Host == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return nil, fmt.Errorf("parse http source %q: expected the URL of an archive", raw)
+	}
+	out := HTTP{URL: u.String(), Subdir: subDir, Normalized: u.String()}
+	prefix := *u
+	prefix.Path = path.Dir(u.Path) + "/"
+	prefix.RawPath, prefix.RawQuery, prefix.Fragment = "", "", ""
+	out.Package = prefix.String()
+	if subDir != "" {
+		out.Normalized += "//" + subDir
+	}
+	return &out, nil
+}
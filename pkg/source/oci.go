@@ -0,0 +1,58 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+// OCI is a module package in an OCI distribution registry,
+// given as `oci://<REGISTRY>/<REPOSITORY>[//<SUBDIR>][?tag=<TAG>|?digest=<DIGEST>]`.
+// ref.: https://opentofu.org/docs/language/modules/sources/#oci-distribution-registries
+type OCI struct {
+	Registry   string
+	Repository string
+	Tag        *string
+	Digest     *string
+	Subdir     string
+	// Package is the normalized package address (<REGISTRY>/<REPOSITORY>), without Subdir.
+	Package    string
+	Normalized string
+}
+
+const ociScheme = "oci://"
+
+func parseOCI(raw string) (*OCI, error) {
+	dir, subDir := getter.SourceDirSubdir(strings.TrimPrefix(raw, ociScheme))
+	u, err := url.Parse(ociScheme + dir)
+	if err != nil {
+		return nil, fmt.Errorf("parse oci source: %w", err)
+	}
+	repository := strings.Trim(u.Path, "/")
+	if u.Host == "" || repository == "" {
+		return nil, fmt.Errorf("parse oci source %q: expected oci://<registry>/<repository>", raw)
+	}
+	out := OCI{
+		Registry:   u.Host,
+		Repository: repository,
+		Subdir:     subDir,
+		Package:    u.Host + "/" + repository,
+	}
+	query := u.Query()
+	if tag := query.Get("tag"); tag != "" {
+		out.Tag = &tag
+	}
+	if digest := query.Get("digest"); digest != "" {
+		out.Digest = &digest
+	}
+	if out.Tag != nil && out.Digest != nil {
+		return nil, fmt.Errorf("parse oci source %q: specify either a tag or a digest, not both", raw)
+	}
+	out.Normalized = out.Package
+	if subDir != "" {
+		out.Normalized += "//" + subDir
+	}
+	return &out, nil
+}
@@ -0,0 +1,38 @@
+package source
+
+import (
+	"fmt"
+
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+)
+
+type Provider struct {
+	Hostname   string
+	Namespace  string
+	Type       string
+	Normalized string
+}
+
+// ParseProvider parses a provider source address. Like Terraform, it treats a
+// bare provider type as an implied provider in the "hashicorp" namespace.
+func ParseProvider(raw string) (*Source, error) {
+	provider, err := tfaddr.ParseProviderSource(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse provider source: %w", err)
+	}
+	if !provider.HasKnownNamespace() {
+		provider = tfaddr.NewProvider(provider.Hostname, "hashicorp", provider.Type)
+	}
+	if provider.IsBuiltIn() {
+		return nil, fmt.Errorf("%w: built-in provider %v", ErrSourceNotSupported, raw)
+	}
+	out := &Source{
+		Provider: &Provider{
+			Hostname:   provider.Hostname.String(),
+			Namespace:  provider.Namespace,
+			Type:       provider.Type,
+			Normalized: provider.String(),
+		},
+	}
+	return out, nil
+}
@@ -5,5 +5,9 @@ type Registry struct {
 	Namespace    string
 	Name         string
 	TargetSystem string
-	Normalized   string
+	// Subdir is the module's directory within the package (given as `//subdir`), if any.
+	Subdir string
+	// Package is the normalized package address, without Subdir.
+	Package    string
+	Normalized string
 }
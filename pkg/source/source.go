@@ -3,6 +3,7 @@ package source
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	tfaddr "github.com/hashicorp/terraform-registry-address"
 )
@@ -11,6 +12,11 @@ type Source struct {
 	Git      *Git
 	Registry *Registry
 	Local    *string
+	Provider *Provider
+	Core     *Core
+	OCI      *OCI
+	Bucket   *Bucket
+	HTTP     *HTTP
 }
 
 func (s Source) Type() string {
@@ -21,6 +27,16 @@ func (s Source) Type() string {
 		return "registry"
 	case s.Local != nil:
 		return "local"
+	case s.Provider != nil:
+		return "provider"
+	case s.Core != nil:
+		return "core"
+	case s.OCI != nil:
+		return "oci"
+	case s.Bucket != nil:
+		return s.Bucket.Service
+	case s.HTTP != nil:
+		return "http"
 	}
 	return ""
 }
@@ -33,6 +49,49 @@ func (s Source) URI() string {
 		return s.Registry.Normalized
 	case s.Local != nil:
 		return *s.Local
+	case s.Provider != nil:
+		return s.Provider.Normalized
+	case s.Core != nil:
+		return s.Core.Product
+	case s.OCI != nil:
+		return s.OCI.Normalized
+	case s.Bucket != nil:
+		return s.Bucket.Normalized
+	case s.HTTP != nil:
+		return s.HTTP.Normalized
+	}
+	return ""
+}
+
+// PackageURI identifies the package the source's versions are published for,
+// i.e. the URI without any subdirectory within the package.
+func (s Source) PackageURI() string {
+	switch {
+	case s.Registry != nil:
+		return s.Registry.Package
+	case s.OCI != nil:
+		return s.OCI.Package
+	case s.Bucket != nil:
+		return s.Bucket.Package
+	case s.HTTP != nil:
+		return s.HTTP.Package
+	}
+	return s.URI()
+}
+
+// Subdir is the source's directory within its package or repository, if any.
+func (s Source) Subdir() string {
+	switch {
+	case s.Registry != nil:
+		return s.Registry.Subdir
+	case s.Git != nil && s.Git.RemotePath != nil:
+		return *s.Git.RemotePath
+	case s.OCI != nil:
+		return s.OCI.Subdir
+	case s.Bucket != nil:
+		return s.Bucket.Subdir
+	case s.HTTP != nil:
+		return s.HTTP.Subdir
 	}
 	return ""
 }
@@ -40,6 +99,13 @@ func (s Source) URI() string {
 var ErrSourceNotSupported = errors.New("source not supported")
 
 func Parse(raw string) (*Source, error) {
+	if strings.HasPrefix(raw, ociScheme) {
+		oci, err := parseOCI(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{OCI: oci}, nil
+	}
 	if module, err := tfaddr.ParseModuleSource(raw); err == nil {
 		out := &Source{
 			Registry: &Registry{
@@ -47,6 +113,8 @@ func Parse(raw string) (*Source, error) {
 				Namespace:    module.Package.Namespace,
 				Name:         module.Package.Name,
 				TargetSystem: module.Package.TargetSystem,
+				Subdir:       module.Subdir,
+				Package:      module.Package.ForDisplay(),
 				Normalized:   module.ForDisplay(),
 			},
 		}
@@ -65,6 +133,24 @@ func Parse(raw string) (*Source, error) {
 		return &Source{Git: git}, nil
 	case "file":
 		return &Source{Local: &detected}, nil
+	case "s3":
+		bucket, err := parseS3(detected)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Bucket: bucket}, nil
+	case "gcs":
+		bucket, err := parseGCS(detected)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Bucket: bucket}, nil
+	case "http", "https":
+		http, err := parseHTTP(detected)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{HTTP: http}, nil
 	default:
 		return nil, fmt.Errorf("%w: %v (%v)", ErrSourceNotSupported, proto, raw)
 	}
@@ -75,6 +75,7 @@ func TestParse(t *testing.T) {
 					Namespace:    "hashicorp",
 					Name:         "consul",
 					TargetSystem: "aws",
+					Package:      "hashicorp/consul/aws",
 					Normalized:   "hashicorp/consul/aws",
 				},
 			},
@@ -87,10 +88,114 @@ func TestParse(t *testing.T) {
 					Namespace:    "HashiCorp",
 					Name:         "Consul",
 					TargetSystem: "aws",
+					Package:      "example.com:1234/HashiCorp/Consul/aws",
 					Normalized:   "example.com:1234/HashiCorp/Consul/aws",
 				},
 			},
 		},
+		{
+			raw: "terraform-aws-modules/iam/aws//modules/iam-role",
+			want: &Source{
+				Registry: &Registry{
+					Hostname:     "registry.terraform.io",
+					Namespace:    "terraform-aws-modules",
+					Name:         "iam",
+					TargetSystem: "aws",
+					Subdir:       "modules/iam-role",
+					Package:      "terraform-aws-modules/iam/aws",
+					Normalized:   "terraform-aws-modules/iam/aws//modules/iam-role",
+				},
+			},
+		},
+		{
+			raw: "oci://registry.example.com/modules/vpc//aws?tag=1.2.0",
+			want: &Source{
+				OCI: &OCI{
+					Registry:   "registry.example.com",
+					Repository: "modules/vpc",
+					Tag:        stringPtr("1.2.0"),
+					Subdir:     "aws",
+					Package:    "registry.example.com/modules/vpc",
+					Normalized: "registry.example.com/modules/vpc//aws",
+				},
+			},
+		},
+		{
+			raw:     "oci://registry.example.com",
+			wantErr: true,
+		},
+		{
+			raw: "s3::https://s3.amazonaws.com/bucket/modules/vpc/vpc-1.4.0.zip//aws",
+			want: &Source{
+				Bucket: &Bucket{
+					Service:    "s3",
+					Region:     "us-east-1",
+					Bucket:     "bucket",
+					Key:        "modules/vpc/vpc-1.4.0.zip",
+					Subdir:     "aws",
+					Package:    "s3::https://s3.amazonaws.com/bucket/modules/vpc/",
+					Normalized: "s3::https://s3.amazonaws.com/bucket/modules/vpc/vpc-1.4.0.zip//aws",
+				},
+			},
+		},
+		{
+			raw: "bucket.s3-eu-west-1.amazonaws.com/modules/vpc-1.4.0.zip",
+			want: &Source{
+				Bucket: &Bucket{
+					Service:    "s3",
+					Region:     "eu-west-1",
+					Bucket:     "bucket",
+					Key:        "modules/vpc-1.4.0.zip",
+					Package:    "s3::https://s3-eu-west-1.amazonaws.com/bucket/modules/",
+					Normalized: "s3::https://s3-eu-west-1.amazonaws.com/bucket/modules/vpc-1.4.0.zip",
+				},
+			},
+		},
+		{
+			raw: "s3::http://127.0.0.1:9000/bucket/vpc-1.4.0.zip?region=local",
+			want: &Source{
+				Bucket: &Bucket{
+					Service:    "s3",
+					Endpoint:   "http://127.0.0.1:9000",
+					Region:     "local",
+					Bucket:     "bucket",
+					Key:        "vpc-1.4.0.zip",
+					Package:    "s3::http://127.0.0.1:9000/bucket/",
+					Normalized: "s3::http://127.0.0.1:9000/bucket/vpc-1.4.0.zip",
+				},
+			},
+		},
+		{
+			raw: "www.googleapis.com/storage/v1/bucket/modules/vpc-1.4.0.zip",
+			want: &Source{
+				Bucket: &Bucket{
+					Service:    "gcs",
+					Bucket:     "bucket",
+					Key:        "modules/vpc-1.4.0.zip",
+					Package:    "gcs::https://www.googleapis.com/storage/v1/bucket/modules/",
+					Normalized: "gcs::https://www.googleapis.com/storage/v1/bucket/modules/vpc-1.4.0.zip",
+				},
+			},
+		},
+		{
+			raw:     "s3::https://s3.amazonaws.com/bucket",
+			wantErr: true,
+		},
+		{
+			raw: "https://artifacts.internal/modules/dns-1.2.0.tar.gz//aws",
+			want: &Source{
+				HTTP: &HTTP{
+					URL:        "https://artifacts.internal/modules/dns-1.2.0.tar.gz",
+					Subdir:     "aws",
+					Package:    "https://artifacts.internal/modules/",
+					Normalized: "https://artifacts.internal/modules/dns-1.2.0.tar.gz//aws",
+				},
+			},
+		},
+		{
+			raw:     "https://artifacts.internal",
+			wantErr: true,
+		},
 		{
 			raw: "github.com/hashicorp/terraform-aws-consul",
 			want: &Source{
@@ -113,3 +218,61 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProvider(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    *Source
+		wantErr bool
+	}{
+		{
+			raw: "hashicorp/aws",
+			want: &Source{
+				Provider: &Provider{
+					Hostname:   "registry.terraform.io",
+					Namespace:  "hashicorp",
+					Type:       "aws",
+					Normalized: "registry.terraform.io/hashicorp/aws",
+				},
+			},
+		},
+		{
+			raw: "random",
+			want: &Source{
+				Provider: &Provider{
+					Hostname:   "registry.terraform.io",
+					Namespace:  "hashicorp",
+					Type:       "random",
+					Normalized: "registry.terraform.io/hashicorp/random",
+				},
+			},
+		},
+		{
+			raw: "example.com/Acme/Thing",
+			want: &Source{
+				Provider: &Provider{
+					Hostname:   "example.com",
+					Namespace:  "acme",
+					Type:       "thing",
+					Normalized: "example.com/acme/thing",
+				},
+			},
+		},
+		{
+			raw:     "terraform.io/builtin/terraform",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseProvider(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseProvider(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("ParseProvider(%q):\n%s", tt.raw, diff)
+			}
+		})
+	}
+}
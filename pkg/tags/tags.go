@@ -0,0 +1,146 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// VersionGroup is the name of the regular expression group that captures the version part of a tag.
+const VersionGroup = "version"
+
+var errNoVersionGroup = fmt.Errorf("tag pattern must have a named group (?P<%s>...)", VersionGroup)
+
+// Pattern extracts versions from tag names, e.g. `1.4.0` from the monorepo tag `vpc/v1.4.0`.
+type Pattern struct {
+	regexp *regexp.Regexp
+	prefix string
+}
+
+// NewRegexp returns a Pattern that matches tags against the given regular expression,
+// taking the version from its `version` group.
+func NewRegexp(expr string) (*Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parse tag pattern %q: %w", expr, err)
+	}
+	if re.SubexpIndex(VersionGroup) < 0 {
+		return nil, fmt.Errorf("parse tag pattern %q: %w", expr, errNoVersionGroup)
+	}
+	return &Pattern{regexp: re}, nil
+}
+
+// NewPrefix returns a Pattern that matches tags starting with prefix, taking the rest as the version.
+func NewPrefix(prefix string) *Pattern {
+	return &Pattern{prefix: prefix}
+}
+
+// Version returns the version part of the given tag name, and whether the tag matches the pattern.
+// A nil Pattern matches all tags, taking the whole name as the version.
+func (p *Pattern) Version(tag string) (string, bool) {
+	switch {
+	case p == nil:
+		return tag, true
+	case p.regexp != nil:
+		match := p.regexp.FindStringSubmatch(tag)
+		if match == nil {
+			return "", false
+		}
+		return match[p.regexp.SubexpIndex(VersionGroup)], true
+	default:
+		version, ok := strings.CutPrefix(tag, p.prefix)
+		if !ok {
+			return "", false
+		}
+		return version, true
+	}
+}
+
+var (
+	archiveExtension = regexp.MustCompile(`(?i)(\.tar)?\.[a-z][a-z0-9]*$`)
+	semverLike       = `v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`
+	versionLike      = regexp.MustCompile(semverLike)
+)
+
+// Infer returns a Pattern matching the names that differ from the given (file) name only in their version,
+// e.g. `vpc-1.5.0.zip` for `vpc-1.4.0.zip`, or nil if the name holds no version.
+func Infer(name string) *Pattern {
+	stem := strings.TrimSuffix(name, archiveExtension.FindString(name))
+	loc := versionLike.FindStringIndex(stem)
+	if loc == nil {
+		return nil
+	}
+	before, after := name[:loc[0]], name[loc[1]:]
+	expr := "^" + regexp.QuoteMeta(before) + "(?P<" + VersionGroup + ">" + semverLike + ")" + regexp.QuoteMeta(after) + "$"
+	return &Pattern{regexp: regexp.MustCompile(expr)}
+}
+
+// Rule selects a Pattern for the git (and bucket) sources of matching module calls.
+type Rule struct {
+	// Remote matches the remote's host and path (e.g. `github.com/acme/infra`); nil matches all remotes.
+	Remote glob.Glob
+	// Module matches the module call's name; nil matches all module calls.
+	Module  glob.Glob
+	Pattern *Pattern
+}
+
+type Rules []Rule
+
+var errEmptyRule = errors.New("tag pattern rule needs a regex or a prefix")
+
+// NewRule compiles a rule from its (possibly empty) remote and module globs and
+// either a regular expression or a prefix.
+func NewRule(remote, module, expr, prefix string) (*Rule, error) {
+	var out Rule
+	var err error
+	switch {
+	case expr != "" && prefix != "":
+		return nil, fmt.Errorf("tag pattern rule: specify either a regex or a prefix, not both")
+	case expr != "":
+		out.Pattern, err = NewRegexp(expr)
+		if err != nil {
+			return nil, err
+		}
+	case prefix != "":
+		out.Pattern = NewPrefix(prefix)
+	default:
+		return nil, errEmptyRule
+	}
+	if remote != "" {
+		out.Remote, err = glob.Compile(remote, '/')
+		if err != nil {
+			return nil, fmt.Errorf("parse remote glob %q: %w", remote, err)
+		}
+	}
+	if module != "" {
+		out.Module, err = glob.Compile(module)
+		if err != nil {
+			return nil, fmt.Errorf("parse module glob %q: %w", module, err)
+		}
+	}
+	return &out, nil
+}
+
+// Match returns the pattern for the given remote (host and path) and module call name, or nil if no rule matches.
+// Rules that select module calls take precedence over rules that don't; otherwise, the first matching rule wins.
+func (r Rules) Match(remote, module string) *Pattern {
+	var fallback *Pattern
+	for _, rule := range r {
+		if rule.Remote != nil && !rule.Remote.Match(remote) {
+			continue
+		}
+		if rule.Module == nil {
+			if fallback == nil {
+				fallback = rule.Pattern
+			}
+			continue
+		}
+		if rule.Module.Match(module) {
+			return rule.Pattern
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,91 @@
+package tags
+
+import (
+	"testing"
+)
+
+func TestPatternVersion(t *testing.T) {
+	monorepo, err := NewRegexp(`^vpc/v(?P<version>\d+\.\d+\.\d+)$`)
+	if err != nil {
+		t.Fatalf("NewRegexp: %v", err)
+	}
+	tests := []struct {
+		pattern *Pattern
+		tag     string
+		want    string
+		wantOK  bool
+	}{
+		{nil, "v1.2.3", "v1.2.3", true},
+		{monorepo, "vpc/v1.4.0", "1.4.0", true},
+		{monorepo, "network/v1.4.0", "", false},
+		{monorepo, "vpc/v1.4.0-rc1", "", false},
+		{NewPrefix("network-module-"), "network-module-2.3.1", "2.3.1", true},
+		{NewPrefix("network-module-"), "vpc-module-2.3.1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.pattern.Version(tt.tag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Version(%q): got (%q, %v), want (%q, %v)", tt.tag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNewRegexpRequiresVersionGroup(t *testing.T) {
+	if _, err := NewRegexp(`^vpc/v(.+)$`); err == nil {
+		t.Errorf("NewRegexp: expected an error for a pattern without a version group")
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	mustRule := func(remote, module, expr, prefix string) Rule {
+		t.Helper()
+		rule, err := NewRule(remote, module, expr, prefix)
+		if err != nil {
+			t.Fatalf("NewRule: %v", err)
+		}
+		return *rule
+	}
+	byRemote := mustRule("github.com/acme/*", "", "", "infra-")
+	byModule := mustRule("github.com/acme/infra", "vpc*", "", "vpc/")
+	rules := Rules{byRemote, byModule}
+
+	tests := []struct {
+		remote, module string
+		want           *Pattern
+	}{
+		{"github.com/acme/infra", "vpc_main", byModule.Pattern},
+		{"github.com/acme/infra", "network", byRemote.Pattern},
+		{"github.com/acme/other", "vpc_main", byRemote.Pattern},
+		{"github.com/other/infra", "vpc_main", nil},
+		{"github.com/acme/infra/nested", "network", nil},
+	}
+	for _, tt := range tests {
+		if got := rules.Match(tt.remote, tt.module); got != tt.want {
+			t.Errorf("Match(%q, %q): got %v, want %v", tt.remote, tt.module, got, tt.want)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		example, name string
+		want          string
+		wantOK        bool
+	}{
+		{"vpc-1.4.0.zip", "vpc-1.5.0.zip", "1.5.0", true},
+		{"vpc-1.4.0.zip", "vpc-2.0.0-rc.1.zip", "2.0.0-rc.1", true},
+		{"vpc-1.4.0.zip", "network-1.5.0.zip", "", false},
+		{"vpc-1.4.0.zip", "vpc-1.5.0.tar.gz", "", false},
+		{"vpc_v1.4.0.tar.gz", "vpc_v1.5.0.tar.gz", "v1.5.0", true},
+		{"1.4.0/vpc.zip", "1.5.0/vpc.zip", "1.5.0", true},
+	}
+	for _, tt := range tests {
+		got, ok := Infer(tt.example).Version(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Infer(%q).Version(%q): got (%q, %v), want (%q, %v)", tt.example, tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if Infer("vpc.zip") != nil {
+		t.Errorf("Infer(%q): expected nil for a name without a version", "vpc.zip")
+	}
+}
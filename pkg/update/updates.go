@@ -1,45 +1,137 @@
 package update
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/archive"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/bucket"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/cache"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/modulecall"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/oci"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/registry"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/rewrite"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/source"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/versions"
 )
 
 type Client struct {
-	Registry      registry.Client
-	GitAuth       transport.AuthMethod
-	VersionsCache map[string][]*semver.Version
+	Registry registry.Client
+	OCI      oci.Client
+	S3       bucket.S3
+	GCS      bucket.GCS
+	// HTTP is used for requests to sources other than module registries (defaults to http.DefaultClient).
+	HTTP *http.Client
+	// ArchiveHTTP is used to list the archives of HTTP sources (defaults to HTTP).
+	ArchiveHTTP *http.Client
+	// CoreReleasesURL is the location of the releases index for Terraform core versions.
+	CoreReleasesURL string
+	// RequestTimeout, if non-zero, limits the time spent fetching the versions of a single source.
+	RequestTimeout time.Duration
+	// GitCredentials picks the credentials for each git remote (nil: anonymous access).
+	GitCredentials GitCredentials
+	// VersionsCache holds the version listings by package URI (see source.Source.PackageURI).
+	VersionsCache map[string]*cache.Entry
+	// Cache, if set, persists version lists across runs.
+	Cache *cache.Dir
+	// Refresh bypasses reads from Cache (fetched versions are still written to it).
+	Refresh bool
+	// MinAge, if non-zero, holds back versions released less than MinAge ago (by registry publish dates or git tag dates)
+	// from the latest versions; they are reported in Update.Pending instead. Versions without a known date are not held back.
+	MinAge time.Duration
+	// Rewrites redirect lookups (e.g. to mirrors); sources are still cached and reported under their original addresses.
+	Rewrites rewrite.Rules
+	// AheadBehindDepth, if non-zero, limits the history fetched by AheadBehind to this many commits per ref.
+	AheadBehindDepth int
+
+	mu          sync.Mutex
+	inFlight    map[string]*versionsCall
+	comparisons map[string]*comparison
+	// moduleServices are the module registry services discovered by Module, by hostname.
+	moduleServices map[string]*registry.Service
+	// tagDateCache holds the fetched dates of git tags, by remote and tag name (see tagDateKey).
+	tagDateCache map[string]time.Time
+}
+
+// GitCredentials picks the credentials for git remotes, e.g. gitauth.Resolver.
+type GitCredentials interface {
+	// Auth returns the credentials for the given remote URL, or nil to access it anonymously.
+	Auth(ctx context.Context, remote string) (transport.AuthMethod, error)
+}
+
+// versionsCall is a versions fetch in progress.
+type versionsCall struct {
+	done    chan struct{}
+	listing *cache.Entry
+	err     error
 }
 
+// comparison is a branch/tag comparison of AheadBehind, made once per remote, branch and tag.
+type comparison struct {
+	done          chan struct{}
+	ahead, behind int
+	err           error
+}
+
+// DefaultAheadBehindDepth is the default for Client.AheadBehindDepth.
+const DefaultAheadBehindDepth = 1000
+
+// DefaultCoreReleasesURL is the official releases index for Terraform core versions.
+const DefaultCoreReleasesURL = "https://releases.hashicorp.com/terraform/index.json"
+
 type Update struct {
 	LatestMatchingVersion string
 	LatestOverallVersion  string
 	LatestMatchingUpdate  string
 	LatestOverallUpdate   string
+	// CurrentVersion is the name the current version is published under, if it is among the listed versions.
+	CurrentVersion string
+	// Release dates by version name (CurrentVersion and the latest versions), if known (see Client.AddTagDates).
+	Released map[string]time.Time
+	// Pending are the versions newer than the latest versions that are held back by Client.MinAge, in ascending order.
+	Pending []string
+	// CooldownErr, if set, is why the release dates of some versions couldn't be fetched for Client.MinAge;
+	// those versions are not held back.
+	CooldownErr error
 }
 
-func (c *Client) Update(s source.Source, current *semver.Version, constraints *semver.Constraints, includePrerelease bool) (*Update, error) {
-	versions, err := c.Versions(s)
+// now is the reference time for Client.MinAge.
+var now = time.Now
+
+// tagDatesBatch is the number of git tags whose dates cooldown fetches at once.
+const tagDatesBatch = 4
+
+func (c *Client) Update(ctx context.Context, s source.Source, current *semver.Version, constraints modulecall.Constraints, includePrerelease bool) (*Update, error) {
+	versions, err := c.Versions(ctx, s)
 	if err != nil {
 		return nil, err
 	}
 	var out Update
+	pending := c.cooldown(ctx, s, versions, current, constraints, includePrerelease, &out)
 	for _, v := range versions {
-		if !includePrerelease && v.Prerelease() != "" {
+		if !includePrerelease && v.Prerelease() != "" || pending[v.Name] {
 			continue
 		}
-		versionString := v.Original()
+		versionString := v.Name
 		out.LatestOverallVersion = versionString
+		if current != nil && v.Equal(current) {
+			out.CurrentVersion = versionString
+		}
 		if current != nil && !v.GreaterThan(current) {
 			continue
 		}
 		out.LatestOverallUpdate = versionString
-		if constraints == nil || !constraints.Check(v) {
+		if constraints == nil || !constraints.Check(v.Version) {
 			continue
 		}
 		out.LatestMatchingVersion = versionString
@@ -50,33 +142,460 @@ func (c *Client) Update(s source.Source, current *semver.Version, constraints *s
 	return &out, nil
 }
 
-func (c *Client) Versions(s source.Source) ([]*semver.Version, error) {
+// cooldown finds the versions newer than current that are held back by MinAge, recording them in u.Pending.
+// Going down from the newest version, it stops once the latest (matching) versions old enough are found,
+// so only the dates of the versions that could be suggested are fetched (for git sources, in batches of
+// tagDatesBatch tags). Versions whose dates can't be fetched are not held back; the errors are recorded in u.CooldownErr.
+func (c *Client) cooldown(ctx context.Context, s source.Source, candidates []versions.Tagged, current *semver.Version, constraints modulecall.Constraints, includePrerelease bool, u *Update) map[string]bool {
+	if c.MinAge <= 0 || s.Registry == nil && s.Git == nil {
+		return nil
+	}
+	cutoff := now().Add(-c.MinAge)
+	tagDates := make(map[string]time.Time)
+	// the dates of the git tags candidates[dated:] have been fetched
+	dated := len(candidates)
+	pending := make(map[string]bool)
+	var errs []error
+	foundOverall, foundMatching := false, constraints == nil
+	for i := len(candidates) - 1; i >= 0 && !(foundOverall && foundMatching); i-- {
+		v := candidates[i]
+		if !includePrerelease && v.Prerelease() != "" {
+			continue
+		}
+		if current != nil && !v.GreaterThan(current) {
+			break
+		}
+		matching := constraints != nil && constraints.Check(v.Version)
+		if foundOverall && !matching {
+			continue
+		}
+		if s.Git != nil && i < dated {
+			var batch []string
+			for dated = i; dated >= 0 && len(batch) < tagDatesBatch; dated-- {
+				w := candidates[dated]
+				if current != nil && !w.GreaterThan(current) {
+					break
+				}
+				if includePrerelease || w.Prerelease() == "" {
+					batch = append(batch, w.Name)
+				}
+			}
+			dated++
+			dates, err := c.tagDates(ctx, s, batch...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			maps.Copy(tagDates, dates)
+		}
+		released, ok := tagDates[v.Name]
+		if s.Registry != nil {
+			if module, err := c.Module(ctx, s, v.Name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", v.Name, err))
+			} else {
+				released, ok = module.PublishedAt, !module.PublishedAt.IsZero()
+			}
+		}
+		if ok {
+			if u.Released == nil {
+				u.Released = make(map[string]time.Time)
+			}
+			u.Released[v.Name] = released
+		}
+		if ok && released.After(cutoff) {
+			pending[v.Name] = true
+			u.Pending = append(u.Pending, v.Name)
+			continue
+		}
+		foundOverall = true
+		foundMatching = foundMatching || matching
+	}
+	slices.Reverse(u.Pending)
+	u.CooldownErr = errors.Join(errs...)
+	return pending
+}
+
+// AddTagDates fetches the dates of the current and latest version tags of a git source into u.Released.
+// The dates fetched are added even if some tags fail.
+func (c *Client) AddTagDates(ctx context.Context, s source.Source, u *Update) error {
+	if s.Git == nil {
+		return source.ErrSourceNotSupported
+	}
+	var tags []string
+	for _, tag := range []string{u.CurrentVersion, u.LatestMatchingVersion, u.LatestOverallVersion} {
+		if _, ok := u.Released[tag]; tag != "" && !ok && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	dates, err := c.tagDates(ctx, s, tags...)
+	if len(dates) > 0 && u.Released == nil {
+		u.Released = make(map[string]time.Time, len(dates))
+	}
+	for tag, date := range dates {
+		u.Released[tag] = date
+	}
+	return err
+}
+
+// tagDates fetches the dates of the given tags of a git source; dates fetched before are reused.
+// Like versions.GitTagDates, it returns the dates it could fetch along with the error for the others.
+func (c *Client) tagDates(ctx context.Context, s source.Source, tags ...string) (map[string]time.Time, error) {
+	remote := c.Rewrites.GitRemote(s.Git.Remote)
+	out := make(map[string]time.Time, len(tags))
+	var missing []string
+	c.mu.Lock()
+	for _, tag := range tags {
+		if date, ok := c.tagDateCache[tagDateKey(remote, tag)]; ok {
+			out[tag] = date
+		} else {
+			missing = append(missing, tag)
+		}
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return out, nil
+	}
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	auth, err := c.gitAuth(ctx, remote)
+	if err != nil {
+		return out, err
+	}
+	dates, err := versions.GitTagDates(ctx, remote, auth, missing...)
+	if err != nil {
+		err = fmt.Errorf("fetch tag dates from %q: %w", remote, err)
+	}
+	c.mu.Lock()
+	if c.tagDateCache == nil {
+		c.tagDateCache = make(map[string]time.Time, len(dates))
+	}
+	for tag, date := range dates {
+		c.tagDateCache[tagDateKey(remote, tag)] = date
+		out[tag] = date
+	}
+	c.mu.Unlock()
+	return out, err
+}
+
+func tagDateKey(remote, tag string) string {
+	return remote + "\x00" + tag
+}
+
+// Versions returns the versions available from the given source, sorted in ascending order.
+// For git sources, the source's tag pattern selects the tags and extracts their versions.
+func (c *Client) Versions(ctx context.Context, s source.Source) ([]versions.Tagged, error) {
+	listing, err := c.listing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return versions.ParseTags(listing.Versions, tagPattern(s)), nil
+}
+
+// TagsAt returns the versions whose tags point at the given commit of a git source, sorted in ascending order.
+// Branches pointing at the commit are not considered, even if their names parse as versions.
+func (c *Client) TagsAt(ctx context.Context, s source.Source, commit string) ([]versions.Tagged, error) {
+	listing, err := c.listing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range listing.Versions {
+		if slices.Contains(listing.Branches, name) {
+			continue
+		}
+		if strings.EqualFold(listing.Hashes[name], commit) {
+			names = append(names, name)
+		}
+	}
+	return versions.ParseTags(names, tagPattern(s)), nil
+}
+
+// Module fetches the registry metadata of a registry source's module at the given version ("": the latest version).
+func (c *Client) Module(ctx context.Context, s source.Source, version string) (*registry.Module, error) {
+	s = c.Rewrites.Apply(s)
+	if s.Registry == nil {
+		return nil, source.ErrSourceNotSupported
+	}
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	reg := s.Registry
+	service, err := c.moduleService(ctx, reg.Hostname)
+	if err != nil {
+		return nil, fmt.Errorf("discover registry at %q: %w", reg.Hostname, err)
+	}
+	module, err := c.Registry.GetModule(ctx, *service, reg.Namespace, reg.Name, reg.TargetSystem, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetch module metadata from registry: %w", err)
+	}
+	return module, nil
+}
+
+// moduleService discovers the module service of a registry host, once per host.
+func (c *Client) moduleService(ctx context.Context, hostname string) (*registry.Service, error) {
+	c.mu.Lock()
+	service, ok := c.moduleServices[hostname]
+	c.mu.Unlock()
+	if ok {
+		return service, nil
+	}
+	service, err := c.Registry.DiscoverModules(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.moduleServices == nil {
+		c.moduleServices = make(map[string]*registry.Service, 1)
+	}
+	c.moduleServices[hostname] = service
+	c.mu.Unlock()
+	return service, nil
+}
+
+// IsBranch reports whether the given ref names a branch of a git source's remote.
+func (c *Client) IsBranch(ctx context.Context, s source.Source, ref string) (bool, error) {
+	listing, err := c.listing(ctx, s)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(listing.Branches, ref), nil
+}
+
+// AheadBehind counts the commits on a git source's branch that aren't reachable from the tag (ahead), and vice versa (behind).
+// Each remote, branch and tag is compared only once; concurrent and later calls share the result.
+func (c *Client) AheadBehind(ctx context.Context, s source.Source, branch, tag string) (ahead, behind int, err error) {
+	if s.Git == nil {
+		return 0, 0, source.ErrSourceNotSupported
+	}
+	remote := c.Rewrites.GitRemote(s.Git.Remote)
+	key := remote + "\x00" + branch + "\x00" + tag
+	c.mu.Lock()
+	if call, ok := c.comparisons[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.ahead, call.behind, call.err
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		}
+	}
+	if c.comparisons == nil {
+		c.comparisons = make(map[string]*comparison, 1)
+	}
+	call := &comparison{done: make(chan struct{})}
+	c.comparisons[key] = call
+	c.mu.Unlock()
+
+	call.ahead, call.behind, call.err = c.compare(ctx, remote, branch, tag)
+	close(call.done)
+	return call.ahead, call.behind, call.err
+}
+
+// compare fetches the histories of a branch and a tag of a git remote and counts the commits ahead and behind.
+func (c *Client) compare(ctx context.Context, remote, branch, tag string) (ahead, behind int, err error) {
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	auth, err := c.gitAuth(ctx, remote)
+	if err != nil {
+		return 0, 0, err
+	}
+	ahead, behind, err = versions.GitAheadBehind(ctx, remote, auth, branch, tag, c.AheadBehindDepth)
+	if err != nil {
+		return 0, 0, fmt.Errorf("compare %s with %s at %q: %w", branch, tag, remote, err)
+	}
+	return ahead, behind, nil
+}
+
+func tagPattern(s source.Source) *tags.Pattern {
+	switch {
+	case s.Git != nil:
+		return s.Git.TagPattern
+	case s.Bucket != nil:
+		return s.Bucket.KeyPattern
+	case s.HTTP != nil:
+		return s.HTTP.NamePattern
+	}
+	return nil
+}
+
+// listing returns the version listing of the given source.
+// It is safe for concurrent use; concurrent calls for the same source share a single fetch.
+func (c *Client) listing(ctx context.Context, s source.Source) (*cache.Entry, error) {
+	key := s.PackageURI()
+	c.mu.Lock()
 	if c.VersionsCache == nil {
-		c.VersionsCache = make(map[string][]*semver.Version, 1)
+		c.VersionsCache = make(map[string]*cache.Entry, 1)
+	}
+	if listing, ok := c.VersionsCache[key]; ok {
+		c.mu.Unlock()
+		return listing, nil
+	}
+	if call, ok := c.inFlight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.listing, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if c.inFlight == nil {
+		c.inFlight = make(map[string]*versionsCall, 1)
+	}
+	call := &versionsCall{done: make(chan struct{})}
+	c.inFlight[key] = call
+	c.mu.Unlock()
+
+	call.listing, call.err = c.cachedListing(ctx, s)
+
+	c.mu.Lock()
+	delete(c.inFlight, key)
+	if call.err == nil {
+		c.VersionsCache[key] = call.listing
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.listing, call.err
+}
+
+// CacheKey identifies a source's package in the persistent cache.
+func CacheKey(s source.Source) string {
+	return s.Type() + ":" + s.PackageURI()
+}
+
+// cachedListing fetches the version listing of s, going through the persistent cache if there is one.
+func (c *Client) cachedListing(ctx context.Context, s source.Source) (*cache.Entry, error) {
+	if c.Cache == nil || s.Local != nil {
+		return c.fetchListing(ctx, s)
+	}
+	key := CacheKey(s)
+	if !c.Refresh {
+		if entry, ok := c.Cache.Get(key); ok {
+			return entry, nil
+		}
+	}
+	listing, err := c.fetchListing(ctx, s)
+	if err != nil {
+		return nil, err
 	}
-	if versions, ok := c.VersionsCache[s.URI()]; ok {
-		return versions, nil
+	listing.Key = key
+	// the cache is best-effort: failing to write it doesn't fail the lookup
+	_ = c.Cache.Put(*listing)
+	return listing, nil
+}
+
+func (c *Client) fetchListing(ctx context.Context, s source.Source) (*cache.Entry, error) {
+	s = c.Rewrites.Apply(s)
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
 	}
 	switch {
 	case s.Git != nil:
 		git := s.Git
-		versions, err := versions.Git(git.Remote, c.GitAuth)
+		auth, err := c.gitAuth(ctx, git.Remote)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := versions.GitRefs(ctx, git.Remote, auth)
 		if err != nil {
 			return nil, fmt.Errorf("fetch versions from %q: %w", git.Remote, err)
 		}
-		c.VersionsCache[s.URI()] = versions
-		return versions, nil
+		return &cache.Entry{Versions: refs.Names, Hashes: refs.Hashes, Branches: refs.Branches}, nil
 	case s.Registry != nil:
 		reg := s.Registry
-		versions, err := versions.Registry(c.Registry, reg.Hostname, reg.Namespace, reg.Name, reg.TargetSystem)
+		versions, err := versions.Registry(ctx, c.Registry, reg.Hostname, reg.Namespace, reg.Name, reg.TargetSystem)
 		if err != nil {
 			return nil, fmt.Errorf("fetch versions from registry: %w", err)
 		}
-		c.VersionsCache[s.URI()] = versions
-		return versions, nil
+		return &cache.Entry{Versions: originals(versions)}, nil
+	case s.Provider != nil:
+		provider := s.Provider
+		versions, err := versions.RegistryProvider(ctx, c.Registry, provider.Hostname, provider.Namespace, provider.Type)
+		if err != nil {
+			return nil, fmt.Errorf("fetch provider versions from registry: %w", err)
+		}
+		return &cache.Entry{Versions: originals(versions)}, nil
+	case s.Core != nil:
+		indexURL := c.CoreReleasesURL
+		if indexURL == "" {
+			indexURL = DefaultCoreReleasesURL
+		}
+		versions, err := versions.Releases(ctx, c.httpClient(), indexURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s versions from %q: %w", s.Core.Product, indexURL, err)
+		}
+		return &cache.Entry{Versions: originals(versions)}, nil
+	case s.OCI != nil:
+		tags, err := c.OCI.ListTags(ctx, s.OCI.Registry, s.OCI.Repository)
+		if err != nil {
+			return nil, fmt.Errorf("fetch tags from %q: %w", s.OCI.Package, err)
+		}
+		return &cache.Entry{Versions: tags}, nil
+	case s.Bucket != nil:
+		objects, err := c.listObjects(ctx, s.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("fetch versions from %q: %w", s.Bucket.Package, err)
+		}
+		return &cache.Entry{Versions: objects}, nil
+	case s.HTTP != nil:
+		client := c.ArchiveHTTP
+		if client == nil {
+			client = c.httpClient()
+		}
+		archives, err := archive.List(ctx, client, s.HTTP.Package, s.HTTP.Index)
+		if err != nil {
+			return nil, fmt.Errorf("fetch versions from %q: %w", s.HTTP.Package, err)
+		}
+		return &cache.Entry{Versions: archives}, nil
 	case s.Local != nil:
-		return nil, nil
+		return &cache.Entry{}, nil
 	default:
 		return nil, source.ErrSourceNotSupported
 	}
 }
+
+func (c *Client) gitAuth(ctx context.Context, remote string) (transport.AuthMethod, error) {
+	if c.GitCredentials == nil {
+		return nil, nil
+	}
+	auth, err := c.GitCredentials.Auth(ctx, remote)
+	if err != nil {
+		return nil, fmt.Errorf("credentials for %q: %w", remote, err)
+	}
+	return auth, nil
+}
+
+func (c *Client) listObjects(ctx context.Context, b *source.Bucket) ([]string, error) {
+	if b.Service == "gcs" {
+		return c.GCS.List(ctx, b.Bucket, b.Prefix())
+	}
+	return c.S3.List(ctx, b.Endpoint, b.Region, b.Bucket, b.Prefix())
+}
+
+// originals returns the versions as they were originally given.
+func originals(versions []*semver.Version) []string {
+	out := make([]string, len(versions))
+	for i, v := range versions {
+		out[i] = v.Original()
+	}
+	return out
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
@@ -0,0 +1,488 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/cache"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/gittest"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/modulecall"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/registry"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/source"
+)
+
+// fakeRegistry serves the given routes as a module registry over TLS, answering
+// service discovery unless a route for it is given.
+func fakeRegistry(t *testing.T, routes map[string]http.HandlerFunc) (client registry.Client, host string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	if _, ok := routes[discoveryPath]; !ok {
+		mux.HandleFunc(discoveryPath, serveDiscovery)
+	}
+	for pattern, handler := range routes {
+		mux.HandleFunc(pattern, handler)
+	}
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return registry.Client{HTTP: server.Client()}, strings.TrimPrefix(server.URL, "https://")
+}
+
+const discoveryPath = "/.well-known/terraform.json"
+
+func serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/"}`))
+}
+
+func TestClientVersionsConcurrent(t *testing.T) {
+	var requests atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		"/v1/modules/hashicorp/consul/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "0.7.3"}, {"version": "0.8.0"}]}]}`))
+		},
+	})
+	client := Client{Registry: registryClient}
+	src, err := source.Parse(host + "/hashicorp/consul/aws")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	const callers = 16
+	var wg sync.WaitGroup
+	counts := make([]int, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			versions, err := client.Versions(context.Background(), *src)
+			counts[i], errs[i] = len(versions), err
+		}()
+	}
+	// callers arriving while the request is blocked wait for it, later ones get the cached listing;
+	// either way, only one request may reach the registry
+	<-started
+	close(release)
+	wg.Wait()
+
+	for i := range counts {
+		if errs[i] != nil {
+			t.Fatalf("Versions: %v", errs[i])
+		}
+		if counts[i] != 2 {
+			t.Errorf("Versions: got %d versions, want 2", counts[i])
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d version list requests, want 1", got)
+	}
+}
+
+func TestClientVersionsSubdirSharesPackage(t *testing.T) {
+	var requests atomic.Int32
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		"/v1/modules/terraform-aws-modules/iam/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "5.0.0"}, {"version": "5.1.0"}]}]}`))
+		},
+	})
+	client := Client{Registry: registryClient}
+	for _, raw := range []string{
+		host + "/terraform-aws-modules/iam/aws",
+		host + "/terraform-aws-modules/iam/aws//modules/iam-role",
+		host + "/terraform-aws-modules/iam/aws//modules/iam-user",
+	} {
+		src, err := source.Parse(raw)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", raw, err)
+		}
+		versions, err := client.Versions(context.Background(), *src)
+		if err != nil {
+			t.Fatalf("Versions(%q): %v", raw, err)
+		}
+		if len(versions) != 2 {
+			t.Errorf("Versions(%q): got %d versions, want 2", raw, len(versions))
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d version list requests, want 1", got)
+	}
+}
+
+func TestUpdateMinAge(t *testing.T) {
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC) }
+	published := map[string]string{
+		"1.0.0": "2024-01-01T00:00:00Z",
+		"1.1.0": "2024-06-08T00:00:00Z",
+		"2.0.0": "2024-05-01T00:00:00Z",
+		"2.1.0": "2024-06-09T00:00:00Z",
+	}
+	var fetched []string
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		"/v1/modules/acme/vpc/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "1.0.0"}, {"version": "1.1.0"}, {"version": "2.0.0"}, {"version": "2.1.0"}]}]}`))
+		},
+		"/v1/modules/acme/vpc/aws/{version}": func(w http.ResponseWriter, r *http.Request) {
+			version := r.PathValue("version")
+			fetched = append(fetched, version)
+			_, _ = fmt.Fprintf(w, `{"version": %q, "published_at": %q}`, version, published[version])
+		},
+	})
+	client := Client{Registry: registryClient, MinAge: 7 * 24 * time.Hour}
+	src, err := source.Parse(host + "/acme/vpc/aws")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	constraints, err := modulecall.NewTerraformConstraints("~> 1.0")
+	if err != nil {
+		t.Fatalf("NewTerraformConstraints: %v", err)
+	}
+	got, err := client.Update(context.Background(), *src, semver.MustParse("1.0.0"), constraints, false)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	want := &Update{
+		LatestOverallVersion: "2.0.0",
+		LatestOverallUpdate:  "2.0.0",
+		CurrentVersion:       "1.0.0",
+		Pending:              []string{"1.1.0", "2.1.0"},
+		Released: map[string]time.Time{
+			"1.1.0": time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC),
+			"2.0.0": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			"2.1.0": time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Update: (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"2.1.0", "2.0.0", "1.1.0"}, fetched); diff != "" {
+		t.Errorf("fetched module versions: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateMinAgeUnknownDate(t *testing.T) {
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		"/v1/modules/acme/vpc/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "1.0.0"}, {"version": "1.1.0"}]}]}`))
+		},
+		"/v1/modules/acme/vpc/aws/{version}": http.NotFound,
+	})
+	client := Client{Registry: registryClient, MinAge: 7 * 24 * time.Hour}
+	src, err := source.Parse(host + "/acme/vpc/aws")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got, err := client.Update(context.Background(), *src, semver.MustParse("1.0.0"), nil, false)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !errors.Is(got.CooldownErr, registry.ErrNotFound) {
+		t.Errorf("Update: got CooldownErr %v, want %v", got.CooldownErr, registry.ErrNotFound)
+	}
+	got.CooldownErr = nil
+	want := &Update{LatestOverallVersion: "1.1.0", LatestOverallUpdate: "1.1.0", CurrentVersion: "1.0.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Update: (-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateMinAgeGit(t *testing.T) {
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC) }
+	released := map[string]time.Time{
+		"v1.0.0": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"v1.1.0": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"v2.0.0": time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC),
+	}
+	repo := gittest.New(t)
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v2.0.0"} {
+		repo.Release(tag, released[tag])
+	}
+	src, err := source.Parse("git::" + repo.URL + "?ref=v1.0.0")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	credentials := &countingCredentials{}
+	client := Client{GitCredentials: credentials, MinAge: 7 * 24 * time.Hour}
+	got, err := client.Update(context.Background(), *src, semver.MustParse("1.0.0"), nil, false)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	want := &Update{
+		LatestOverallVersion: "v1.1.0",
+		LatestOverallUpdate:  "v1.1.0",
+		CurrentVersion:       "v1.0.0",
+		Pending:              []string{"v2.0.0"},
+		Released:             map[string]time.Time{"v1.1.0": released["v1.1.0"], "v2.0.0": released["v2.0.0"]},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Update: (-want +got)\n%s", diff)
+	}
+	// the listing and one fetch of the newer tags
+	if got := credentials.calls.Load(); got != 2 {
+		t.Errorf("got %d git operations, want 2", got)
+	}
+
+	// a tag deleted since it was listed is not held back, and reported
+	client = Client{MinAge: 7 * 24 * time.Hour, VersionsCache: map[string]*cache.Entry{
+		src.PackageURI(): {Versions: []string{"v1.0.0", "v1.1.0", "v2.0.0", "v3.0.0"}},
+	}}
+	got, err = client.Update(context.Background(), *src, semver.MustParse("1.0.0"), nil, false)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !errors.Is(got.CooldownErr, plumbing.ErrReferenceNotFound) {
+		t.Errorf("Update: got CooldownErr %v, want %v", got.CooldownErr, plumbing.ErrReferenceNotFound)
+	}
+	if got.LatestOverallVersion != "v3.0.0" || len(got.Pending) != 0 {
+		t.Errorf("Update: got latest %s and pending %v, want v3.0.0 and none", got.LatestOverallVersion, got.Pending)
+	}
+}
+
+func TestUpdateMinAgeGitBatches(t *testing.T) {
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC) }
+	old, recent := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)
+	tags := []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0", "v1.5.0"}
+	tests := []struct {
+		name        string
+		recent      int
+		wantLatest  string
+		wantPending []string
+		wantDated   []string
+		wantFetches int32
+	}{
+		{name: "newest recent", recent: 1, wantLatest: "v1.4.0", wantPending: []string{"v1.5.0"}, wantDated: tags[4:], wantFetches: 1},
+		{name: "all but oldest recent", recent: 5, wantLatest: "v1.0.0", wantPending: tags[1:], wantDated: tags, wantFetches: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := gittest.New(t)
+			released := make(map[string]time.Time)
+			for i, tag := range tags {
+				released[tag] = old
+				if i >= len(tags)-tt.recent {
+					released[tag] = recent
+				}
+				repo.Release(tag, released[tag])
+			}
+			src, err := source.Parse("git::" + repo.URL)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			credentials := &countingCredentials{}
+			client := Client{GitCredentials: credentials, MinAge: 7 * 24 * time.Hour}
+			got, err := client.Update(context.Background(), *src, nil, nil, false)
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			want := &Update{
+				LatestOverallVersion: tt.wantLatest,
+				LatestOverallUpdate:  tt.wantLatest,
+				Pending:              tt.wantPending,
+				Released:             make(map[string]time.Time),
+			}
+			for _, tag := range tt.wantDated {
+				want.Released[tag] = released[tag]
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Update: (-want +got)\n%s", diff)
+			}
+			// the listing, then one fetch per batch of tags
+			if got := credentials.calls.Load() - 1; got != tt.wantFetches {
+				t.Errorf("got %d tag date fetches, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		"/v1/modules/acme/slow/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		},
+		"/v1/modules/acme/fast/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "1.0.0"}]}]}`))
+		},
+	})
+	client := Client{Registry: registryClient, RequestTimeout: 100 * time.Millisecond}
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	for _, name := range []string{"slow", "fast"} {
+		src, err := source.Parse(host + "/acme/" + name + "/aws")
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Versions(context.Background(), *src)
+			mu.Lock()
+			errs[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if err := errs["slow"]; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Versions(slow): got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := errs["fast"]; err != nil {
+		t.Errorf("Versions(fast): %v", err)
+	}
+}
+
+func TestClientTagsAt(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	src, err := source.Parse("git::https://example.com/acme/modules.git?ref=" + commit)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	client := Client{VersionsCache: map[string]*cache.Entry{
+		src.PackageURI(): {
+			Versions: []string{"main", "v2", "v1.0.0", "v1.1.0", "v2.0.0"},
+			Branches: []string{"main", "v2"},
+			Hashes: map[string]string{
+				"main":   commit,
+				"v2":     commit,
+				"v1.0.0": "fedcba9876543210fedcba9876543210fedcba98",
+				"v1.1.0": commit,
+				"v2.0.0": strings.ToUpper(commit),
+			},
+		},
+	}}
+	tagged, err := client.TagsAt(context.Background(), *src, commit)
+	if err != nil {
+		t.Fatalf("TagsAt: %v", err)
+	}
+	var got []string
+	for _, v := range tagged {
+		got = append(got, v.Name)
+	}
+	if diff := cmp.Diff([]string{"v1.1.0", "v2.0.0"}, got); diff != "" {
+		t.Errorf("TagsAt: (-want +got)\n%s", diff)
+	}
+}
+
+// countingCredentials counts the git operations that ask for credentials.
+type countingCredentials struct{ calls atomic.Int32 }
+
+func (c *countingCredentials) Auth(context.Context, string) (transport.AuthMethod, error) {
+	c.calls.Add(1)
+	return nil, nil
+}
+
+func TestClientAheadBehindOnce(t *testing.T) {
+	// master is two commits ahead of v1.0.0
+	repo := gittest.New(t)
+	repo.Release("v1.0.0", time.Now())
+	repo.Commit(time.Now())
+	repo.Commit(time.Now())
+	src, err := source.Parse("git::" + repo.URL + "?ref=master")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	credentials := &countingCredentials{}
+	client := Client{GitCredentials: credentials, AheadBehindDepth: DefaultAheadBehindDepth}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ahead, behind, err := client.AheadBehind(context.Background(), *src, "master", "v1.0.0")
+			if err != nil || ahead != 2 || behind != 0 {
+				t.Errorf("AheadBehind: got (%d, %d, %v), want (2, 0, nil)", ahead, behind, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, _, err := client.AheadBehind(context.Background(), *src, "master", "v1.0.0"); err != nil {
+		t.Fatalf("AheadBehind: %v", err)
+	}
+	if got := credentials.calls.Load(); got != 1 {
+		t.Errorf("got %d fetches, want 1", got)
+	}
+}
+
+func TestClientAddTagDatesCached(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	repo := gittest.New(t)
+	repo.Release("v1.0.0", when)
+	repo.Release("v1.1.0", when)
+	src, err := source.Parse("git::" + repo.URL + "?ref=v1.0.0")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	credentials := &countingCredentials{}
+	client := Client{GitCredentials: credentials}
+	for i := 0; i < 2; i++ {
+		u := &Update{CurrentVersion: "v1.0.0", LatestMatchingVersion: "v1.1.0", LatestOverallVersion: "v1.1.0"}
+		if err := client.AddTagDates(context.Background(), *src, u); err != nil {
+			t.Fatalf("AddTagDates: %v", err)
+		}
+		want := map[string]time.Time{"v1.0.0": when, "v1.1.0": when}
+		if diff := cmp.Diff(want, u.Released); diff != "" {
+			t.Errorf("AddTagDates: (-want +got)\n%s", diff)
+		}
+	}
+	if got := credentials.calls.Load(); got != 1 {
+		t.Errorf("got %d fetches, want 1", got)
+	}
+
+	u := &Update{CurrentVersion: "v1.0.0", LatestMatchingVersion: "v9.0.0"}
+	if err := client.AddTagDates(context.Background(), *src, u); err == nil {
+		t.Errorf("AddTagDates: got no error for a missing tag")
+	}
+}
+
+func TestClientModuleDiscoversOnce(t *testing.T) {
+	var discoveries atomic.Int32
+	registryClient, host := fakeRegistry(t, map[string]http.HandlerFunc{
+		discoveryPath: func(w http.ResponseWriter, r *http.Request) {
+			discoveries.Add(1)
+			serveDiscovery(w, r)
+		},
+		"/v1/modules/acme/vpc/aws/{version}": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"version": %q}`, r.PathValue("version"))
+		},
+	})
+	client := Client{Registry: registryClient}
+	src, err := source.Parse(host + "/acme/vpc/aws")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		module, err := client.Module(context.Background(), *src, version)
+		if err != nil {
+			t.Fatalf("Module(%s): %v", version, err)
+		}
+		if module.Version != version {
+			t.Errorf("Module(%s): got version %s", version, module.Version)
+		}
+	}
+	if got := discoveries.Load(); got != 1 {
+		t.Errorf("got %d discovery requests, want 1", got)
+	}
+}
@@ -1,18 +1,193 @@
 package versions
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"strings"
+	"time"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
 
-func Git(remoteURL string, auth transport.AuthMethod) ([]*semver.Version, error) {
-	raw, err := git.Init(memory.NewStorage(), nil)
+const peeledSuffix = "^{}"
+
+// GitRefList are the refs of a git remote.
+type GitRefList struct {
+	// Names are the short names of the remote's tags and branches.
+	Names []string
+	// Hashes maps the names to the commits they point at (peeled, for annotated tags).
+	Hashes map[string]string
+	// Branches are the names of the remote's branches.
+	Branches []string
+}
+
+// GitRefs lists the refs of the given remote.
+func GitRefs(ctx context.Context, remoteURL string, auth transport.AuthMethod) (*GitRefList, error) {
+	remote, err := newMemoryRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return nil, fmt.Errorf("git list refs: %w", err)
+	}
+	out := GitRefList{
+		Names:  make([]string, 0, len(refs)),
+		Hashes: make(map[string]string, len(refs)),
+	}
+	peeled := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Type() != plumbing.HashReference {
+			continue
+		}
+		name := ref.Name().Short()
+		if base, ok := strings.CutSuffix(name, peeledSuffix); ok {
+			out.Hashes[base] = ref.Hash().String()
+			peeled[base] = true
+			continue
+		}
+		out.Names = append(out.Names, name)
+		if !peeled[name] {
+			out.Hashes[name] = ref.Hash().String()
+		}
+		if ref.Name().IsBranch() {
+			out.Branches = append(out.Branches, name)
+		}
+	}
+	return &out, nil
+}
+
+// ErrHistoryTruncated is returned by GitAheadBehind when the branch and tag don't meet within the fetched history.
+var ErrHistoryTruncated = errors.New("branch and tag diverge beyond the fetched history")
+
+// GitAheadBehind fetches the histories of the given branch and tag into memory and counts
+// the commits reachable from the branch but not the tag (ahead), and vice versa (behind).
+// A positive depth limits the fetched history of each ref to that many commits.
+func GitAheadBehind(ctx context.Context, remoteURL string, auth transport.AuthMethod, branch, tag string, depth int) (ahead, behind int, err error) {
+	branchRef, tagRef := plumbing.NewBranchReferenceName(branch), plumbing.NewTagReferenceName(tag)
+	remote, err := newMemoryRemote(remoteURL)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		Auth: auth,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(branchRef + ":" + branchRef),
+			config.RefSpec(tagRef + ":" + tagRef),
+		},
+		Depth: depth,
+		Tags:  git.NoTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return 0, 0, fmt.Errorf("git fetch: %w", err)
+	}
+	branchCommits, _, err := ancestors(remote.storage, branchRef, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	tagCommits, _, err := ancestors(remote.storage, tagRef, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	// count the commits of each side down to where it meets the other; if that is beyond the fetched history, the count would be wrong
+	aheadCommits, truncated, err := ancestors(remote.storage, branchRef, tagCommits)
+	if err != nil {
+		return 0, 0, err
+	}
+	behindCommits, truncatedBehind, err := ancestors(remote.storage, tagRef, branchCommits)
+	if err != nil {
+		return 0, 0, err
+	}
+	if truncated || truncatedBehind {
+		return 0, 0, fmt.Errorf("%w (depth %d)", ErrHistoryTruncated, depth)
+	}
+	return len(aheadCommits), len(behindCommits), nil
+}
+
+// GitTagDates returns the dates of the given tags: the tagger date of annotated tags, or the commit date otherwise.
+// It fetches the tags into memory with depth 1, which still transfers the whole tree of each tagged commit,
+// so callers should ask only for the tags they need. Tags missing from the remote or that can't be resolved
+// are left out of the returned dates and reported in the (joined) error.
+func GitTagDates(ctx context.Context, remoteURL string, auth transport.AuthMethod, tags ...string) (map[string]time.Time, error) {
+	remote, err := newMemoryRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, fmt.Errorf("git list refs: %w", err)
+	}
+	exists := make(map[plumbing.ReferenceName]bool, len(refs))
+	for _, ref := range refs {
+		exists[ref.Name()] = true
+	}
+	var errs []error
+	var found []string
+	var refSpecs []config.RefSpec
+	for _, tag := range tags {
+		ref := plumbing.NewTagReferenceName(tag)
+		if !exists[ref] {
+			errs = append(errs, fmt.Errorf("resolve tag %s: %w", tag, plumbing.ErrReferenceNotFound))
+			continue
+		}
+		found = append(found, tag)
+		refSpecs = append(refSpecs, config.RefSpec(ref+":"+ref))
+	}
+	out := make(map[string]time.Time, len(found))
+	if len(found) == 0 {
+		return out, errors.Join(errs...)
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		Auth:     auth,
+		RefSpecs: refSpecs,
+		Depth:    1,
+		Tags:     git.NoTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("git fetch: %w", err)
+	}
+	for _, tag := range found {
+		date, err := tagDate(remote.storage, tag)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out[tag] = date
+	}
+	return out, errors.Join(errs...)
+}
+
+// tagDate returns the tagger date of an annotated tag, or the commit date of a lightweight one.
+func tagDate(s *memory.Storage, tag string) (time.Time, error) {
+	ref, err := storer.ResolveReference(s, plumbing.NewTagReferenceName(tag))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("resolve tag %s: %w", tag, err)
+	}
+	if tagObject, err := object.GetTag(s, ref.Hash()); err == nil {
+		return tagObject.Tagger.When, nil
+	}
+	commit, err := object.GetCommit(s, ref.Hash())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("resolve tag %s: %w", tag, err)
+	}
+	return commit.Committer.When, nil
+}
+
+type memoryRemote struct {
+	*git.Remote
+	storage *memory.Storage
+}
+
+func newMemoryRemote(remoteURL string) (*memoryRemote, error) {
+	storage := memory.NewStorage()
+	raw, err := git.Init(storage, nil)
 	if err != nil {
 		return nil, fmt.Errorf("git init: %w", err)
 	}
@@ -23,18 +198,47 @@ func Git(remoteURL string, auth transport.AuthMethod) ([]*semver.Version, error)
 	if err != nil {
 		return nil, fmt.Errorf("git remote: %w", err)
 	}
-	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	return &memoryRemote{Remote: remote, storage: storage}, nil
+}
+
+// ancestors returns the fetched commits reachable from the given ref (including the one it points at)
+// without passing through the commits in stop. truncated reports whether the walk reached the end of a shallow fetch.
+func ancestors(s *memory.Storage, name plumbing.ReferenceName, stop map[plumbing.Hash]bool) (out map[plumbing.Hash]bool, truncated bool, err error) {
+	ref, err := storer.ResolveReference(s, name)
 	if err != nil {
-		return nil, fmt.Errorf("git list refs: %w", err)
+		return nil, false, fmt.Errorf("resolve %s: %w", name, err)
 	}
-	out := make([]*semver.Version, 0, len(refs))
-	for _, ref := range refs {
-		version, err := semver.NewVersion(ref.Name().Short())
-		if err != nil {
+	commit, err := resolveCommit(s, ref.Hash())
+	if err != nil {
+		return nil, false, fmt.Errorf("resolve %s: %w", name, err)
+	}
+	out = make(map[plumbing.Hash]bool)
+	queue := []plumbing.Hash{commit.Hash}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if out[hash] || stop[hash] {
 			continue
 		}
-		out = append(out, version)
+		commit, err := object.GetCommit(s, hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			truncated = true
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("walk %s: %w", name, err)
+		}
+		out[hash] = true
+		queue = append(queue, commit.ParentHashes...)
+	}
+	return out, truncated, nil
+}
+
+// resolveCommit returns the commit with the given hash, peeling annotated tags.
+func resolveCommit(s *memory.Storage, hash plumbing.Hash) (*object.Commit, error) {
+	tag, err := object.GetTag(s, hash)
+	if err == nil {
+		return tag.Commit()
 	}
-	sort.Sort(semver.Collection(out))
-	return out, nil
+	return object.GetCommit(s, hash)
 }
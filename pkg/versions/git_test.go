@@ -0,0 +1,101 @@
+package versions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/gittest"
+)
+
+func TestGitRefs(t *testing.T) {
+	repo := gittest.New(t)
+	commit := repo.Release("v1.0.0", time.Now())
+	repo.AnnotatedTag("v1.1.0", commit, time.Now())
+
+	refs, err := GitRefs(context.Background(), repo.URL, nil)
+	if err != nil {
+		t.Fatalf("GitRefs: %v", err)
+	}
+	names := refs.Names
+	sort.Strings(names)
+	if diff := cmp.Diff([]string{"master", "v1.0.0", "v1.1.0"}, names); diff != "" {
+		t.Errorf("GitRefs: names (-want +got)\n%s", diff)
+	}
+	want := map[string]string{"master": commit.String(), "v1.0.0": commit.String(), "v1.1.0": commit.String()}
+	if diff := cmp.Diff(want, refs.Hashes); diff != "" {
+		t.Errorf("GitRefs: hashes (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"master"}, refs.Branches); diff != "" {
+		t.Errorf("GitRefs: branches (-want +got)\n%s", diff)
+	}
+}
+
+func TestGitAheadBehind(t *testing.T) {
+	// master: v1.0.0 -> one commit; release: v1.0.0 -> v2.0.0
+	repo := gittest.New(t)
+	base := repo.Commit(time.Now())
+	repo.AnnotatedTag("v1.0.0", base, time.Now())
+	repo.Commit(time.Now())
+	repo.Branch("release", base)
+	repo.Release("v2.0.0", time.Now())
+
+	tests := []struct {
+		branch, tag           string
+		depth                 int
+		wantAhead, wantBehind int
+		wantErr               error
+	}{
+		{branch: "master", tag: "v1.0.0", wantAhead: 1},
+		{branch: "master", tag: "v2.0.0", wantAhead: 1, wantBehind: 1},
+		{branch: "release", tag: "v2.0.0"},
+		{branch: "master", tag: "v1.0.0", depth: 1, wantAhead: 1},
+		{branch: "master", tag: "v2.0.0", depth: 1, wantErr: ErrHistoryTruncated},
+		{branch: "master", tag: "v2.0.0", depth: 2, wantAhead: 1, wantBehind: 1},
+	}
+	for _, tt := range tests {
+		ahead, behind, err := GitAheadBehind(context.Background(), repo.URL, nil, tt.branch, tt.tag, tt.depth)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GitAheadBehind(%s, %s, depth %d): got error %v, want %v", tt.branch, tt.tag, tt.depth, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("GitAheadBehind(%s, %s): %v", tt.branch, tt.tag, err)
+		}
+		if ahead != tt.wantAhead || behind != tt.wantBehind {
+			t.Errorf("GitAheadBehind(%s, %s, depth %d): got %d ahead, %d behind, want %d, %d", tt.branch, tt.tag, tt.depth, ahead, behind, tt.wantAhead, tt.wantBehind)
+		}
+	}
+}
+
+func TestGitTagDates(t *testing.T) {
+	committed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tagged := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	repo := gittest.New(t)
+	for i := 0; i < 3; i++ {
+		repo.Commit(committed.Add(-time.Hour))
+	}
+	commit := repo.Release("v1.0.0", committed)
+	repo.AnnotatedTag("v1.1.0", commit, tagged)
+
+	got, err := GitTagDates(context.Background(), repo.URL, nil, "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatalf("GitTagDates: %v", err)
+	}
+	if !got["v1.0.0"].Equal(committed) || !got["v1.1.0"].Equal(tagged) {
+		t.Errorf("GitTagDates: got %v, want v1.0.0: %v (commit date), v1.1.0: %v (tagger date)", got, committed, tagged)
+	}
+	got, err = GitTagDates(context.Background(), repo.URL, nil, "v1.0.0", "v9.9.9")
+	if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		t.Errorf("GitTagDates: got error %v, want %v", err, plumbing.ErrReferenceNotFound)
+	}
+	if len(got) != 1 || !got["v1.0.0"].Equal(committed) {
+		t.Errorf("GitTagDates: got %v, want only v1.0.0: %v", got, committed)
+	}
+}
@@ -1,42 +1,48 @@
 package versions
 
 import (
+	"context"
 	"fmt"
-	"net/url"
 	"sort"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/registry"
 )
 
-func Registry(client registry.Client, hostname, namespace, name, system string) ([]*semver.Version, error) {
-	baseURL, err := client.Discover(hostname)
+func Registry(ctx context.Context, client registry.Client, hostname, namespace, name, system string) ([]*semver.Version, error) {
+	service, err := client.DiscoverModules(ctx, hostname)
 	if err != nil {
 		return nil, fmt.Errorf("discover registry at %q: %w", hostname, err)
 	}
-	baseURLStruct, err := url.Parse(baseURL)
+	versions, err := client.ListVersions(ctx, *service, namespace, name, system)
 	if err != nil {
-		return nil, fmt.Errorf("parse module registry url %q: %w", baseURL, err)
-	}
-	if baseURLStruct.Scheme == "" {
-		baseURLStruct.Scheme = "https"
+		return nil, fmt.Errorf("list versions: %w", err)
 	}
-	if baseURLStruct.Host == "" {
-		baseURLStruct.Host = hostname
+	return Parse(versions), nil
+}
+
+func RegistryProvider(ctx context.Context, client registry.Client, hostname, namespace, providerType string) ([]*semver.Version, error) {
+	service, err := client.DiscoverProviders(ctx, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("discover registry at %q: %w", hostname, err)
 	}
-	baseURL = baseURLStruct.String()
-	versions, err := client.ListVersions(baseURL, namespace, name, system)
+	versions, err := client.ListProviderVersions(ctx, *service, namespace, providerType)
 	if err != nil {
-		return nil, fmt.Errorf("list versions: %w", err)
+		return nil, fmt.Errorf("list provider versions: %w", err)
 	}
-	out := make([]*semver.Version, len(versions))
-	for i, versionString := range versions {
+	return Parse(versions), nil
+}
+
+// Parse parses the given version strings into a sorted collection, skipping the ones that aren't valid versions.
+func Parse(versions []string) []*semver.Version {
+	out := make([]*semver.Version, 0, len(versions))
+	for _, versionString := range versions {
 		version, err := semver.NewVersion(versionString)
 		if err != nil {
-			version = nil
+			continue
 		}
-		out[i] = version
+		out = append(out, version)
 	}
 	sort.Sort(semver.Collection(out))
-	return out, nil
+	return out
 }
@@ -0,0 +1,80 @@
+package versions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/registry"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+func newFakeRegistry(t *testing.T) (registry.Client, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/", "providers.v1": "/v1/providers/"}`))
+	})
+	mux.HandleFunc("/v1/modules/hashicorp/consul/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "0.8.0"}, {"version": "0.7.3"}, {"version": "not-a-version"}]}]}`))
+	})
+	mux.HandleFunc("/v1/providers/hashicorp/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"versions": [{"version": "5.1.0", "protocols": ["5.0"]}, {"version": "4.67.0"}, {"version": "5.0.0-beta1"}]}`))
+	})
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return registry.Client{HTTP: server.Client()}, strings.TrimPrefix(server.URL, "https://")
+}
+
+func versionStrings(versions []*semver.Version) []string {
+	out := make([]string, len(versions))
+	for i, v := range versions {
+		out[i] = v.Original()
+	}
+	return out
+}
+
+func TestRegistry(t *testing.T) {
+	client, hostname := newFakeRegistry(t)
+	got, err := Registry(context.Background(), client, hostname, "hashicorp", "consul", "aws")
+	if err != nil {
+		t.Fatalf("Registry: %v", err)
+	}
+	if diff := cmp.Diff(versionStrings(got), []string{"0.7.3", "0.8.0"}); diff != "" {
+		t.Errorf("Registry:\n%s", diff)
+	}
+}
+
+func TestRegistryProvider(t *testing.T) {
+	client, hostname := newFakeRegistry(t)
+	got, err := RegistryProvider(context.Background(), client, hostname, "hashicorp", "aws")
+	if err != nil {
+		t.Fatalf("RegistryProvider: %v", err)
+	}
+	if diff := cmp.Diff(versionStrings(got), []string{"4.67.0", "5.0.0-beta1", "5.1.0"}); diff != "" {
+		t.Errorf("RegistryProvider:\n%s", diff)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	pattern, err := tags.NewRegexp(`^vpc/v(?P<version>.+)$`)
+	if err != nil {
+		t.Fatalf("NewRegexp: %v", err)
+	}
+	names := []string{"vpc/v1.10.0", "network/v2.0.0", "vpc/v1.4.0", "main", "vpc/vnext"}
+	got := ParseTags(names, pattern)
+	gotNames := make([]string, len(got))
+	for i, v := range got {
+		gotNames[i] = v.Name
+	}
+	if diff := cmp.Diff(gotNames, []string{"vpc/v1.4.0", "vpc/v1.10.0"}); diff != "" {
+		t.Errorf("ParseTags:\n%s", diff)
+	}
+	if got[1].Original() != "1.10.0" {
+		t.Errorf("ParseTags: got version %q, want %q", got[1].Original(), "1.10.0")
+	}
+}
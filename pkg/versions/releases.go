@@ -0,0 +1,71 @@
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Releases lists the versions in a HashiCorp releases index,
+// e.g. https://releases.hashicorp.com/terraform/index.json.
+// The index may also be read from a local file (given as a path or file:// URL).
+func Releases(ctx context.Context, client *http.Client, indexURL string) ([]*semver.Version, error) {
+	body, err := openReleasesIndex(ctx, client, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var index struct {
+		Versions map[string]struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := json.NewDecoder(body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("decode releases index: %w", err)
+	}
+	versions := make([]string, 0, len(index.Versions))
+	for key, release := range index.Versions {
+		if release.Version == "" {
+			release.Version = key
+		}
+		versions = append(versions, release.Version)
+	}
+	return Parse(versions), nil
+}
+
+func openReleasesIndex(ctx context.Context, client *http.Client, indexURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(indexURL)
+	if err != nil || u.Scheme == "" {
+		return openReleasesIndexFile(indexURL)
+	}
+	if u.Scheme == "file" {
+		return openReleasesIndexFile(u.Path)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", indexURL, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %q: %w", indexURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %q: %s", indexURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func openReleasesIndexFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read releases index: %w", err)
+	}
+	return f, nil
+}
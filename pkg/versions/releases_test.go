@@ -0,0 +1,52 @@
+package versions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReleases(t *testing.T) {
+	path := filepath.Join("testdata", "index.json")
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatalf("Abs: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/terraform/index.json" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, path)
+	}))
+	defer server.Close()
+
+	want := []string{"1.5.7", "1.9.0-rc1", "1.9.8", "1.10.0"}
+	for _, indexURL := range []string{
+		path,
+		"file://" + filepath.ToSlash(absPath),
+		server.URL + "/terraform/index.json",
+	} {
+		releases, err := Releases(context.Background(), server.Client(), indexURL)
+		if err != nil {
+			t.Fatalf("Releases(%q): %v", indexURL, err)
+		}
+		var got []string
+		for _, v := range releases {
+			got = append(got, v.Original())
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Releases(%q): (-want +got)\n%s", indexURL, diff)
+		}
+	}
+
+	for _, indexURL := range []string{filepath.Join("testdata", "missing.json"), server.URL + "/missing.json"} {
+		if _, err := Releases(context.Background(), server.Client(), indexURL); err == nil {
+			t.Errorf("Releases(%q): expected an error", indexURL)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package versions
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/keilerkonzept/terraform-module-versions/v3/pkg/tags"
+)
+
+// Tagged is a version together with the name it is published under,
+// e.g. version 1.4.0 published as the git tag `vpc/v1.4.0`.
+type Tagged struct {
+	*semver.Version
+	Name string
+}
+
+// ParseTags parses the versions from the given names using pattern (nil: the names are the versions),
+// skipping the names that don't match or don't hold a valid version. The result is sorted by version.
+func ParseTags(names []string, pattern *tags.Pattern) []Tagged {
+	out := make([]Tagged, 0, len(names))
+	for _, name := range names {
+		versionString, ok := pattern.Version(name)
+		if !ok {
+			continue
+		}
+		version, err := semver.NewVersion(versionString)
+		if err != nil {
+			continue
+		}
+		out = append(out, Tagged{Version: version, Name: name})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].LessThan(out[j].Version)
+	})
+	return out
+}